@@ -0,0 +1,37 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"go-admin/service"
+
+	"gorm.io/gorm"
+)
+
+const usage = `usage:
+  go-admin                                  start the HTTP server
+  go-admin roles export [-o roles.yaml]     export roles and permissions as YAML
+  go-admin roles import -f roles.yaml [-dry-run]
+                                            import roles from YAML
+  go-admin orders import -f orders.csv -user 1 [-date 2024-01-31] [-update-stock] [-dry-run]
+                                            import historical orders
+  go-admin images gc [-grace 24h] [-delete]  report (or delete) orphaned images
+                                            and images missing from storage`
+
+// Run menjalankan perintah CLI berdasarkan argumen setelah nama program.
+func Run(db *gorm.DB, minioService *service.MinioService, args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "roles":
+		return runRoles(db, args[1:])
+	case "orders":
+		return runOrders(db, args[1:])
+	case "images":
+		return runImages(db, minioService, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
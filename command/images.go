@@ -0,0 +1,49 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go-admin/dto"
+	"go-admin/service"
+
+	"gorm.io/gorm"
+)
+
+func runImages(db *gorm.DB, minioService *service.MinioService, args []string) error {
+	if len(args) == 0 || args[0] != "gc" {
+		return errors.New(usage)
+	}
+
+	fs := flag.NewFlagSet("images gc", flag.ContinueOnError)
+	grace := fs.Duration("grace", service.DefaultImageGCGracePeriod, "only treat objects older than this as orphans")
+	remove := fs.Bool("delete", false, "delete orphaned objects instead of only reporting them")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	gcService := service.NewImageGCService(db, minioService)
+	result, err := gcService.Run(context.Background(), dto.ImageGCOptions{
+		GracePeriod: *grace,
+		Delete:      *remove,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, orphan := range result.Orphans {
+		fmt.Printf("orphan  %s (%d bytes, %s)\n", orphan.Key, orphan.Size, orphan.LastModified.Format("2006-01-02 15:04"))
+	}
+	for _, missing := range result.Missing {
+		fmt.Printf("missing %s #%d: %s\n", missing.Entity, missing.EntityID, missing.Key)
+	}
+	fmt.Printf("objects: %d in bucket, %d referenced\n", result.Objects, result.Referenced)
+	fmt.Printf("orphans: %d found, %d deleted, %d newer than %s kept\n",
+		len(result.Orphans), result.Deleted, result.Recent, *grace)
+	fmt.Printf("missing: %d\n", len(result.Missing))
+	if !*remove && len(result.Orphans) > 0 {
+		fmt.Println("run with -delete to remove orphans")
+	}
+	return nil
+}
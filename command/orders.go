@@ -0,0 +1,67 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go-admin/dto"
+	"go-admin/service"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+func runOrders(db *gorm.DB, args []string) error {
+	if len(args) == 0 || args[0] != "import" {
+		return errors.New(usage)
+	}
+
+	fs := flag.NewFlagSet("orders import", flag.ContinueOnError)
+	file := fs.String("f", "", "CSV/XLSX file to import")
+	userID := fs.Uint("user", 0, "ID of the user recorded as cashier")
+	date := fs.String("date", "", "transaction date for rows without a date column (YYYY-MM-DD)")
+	updateStock := fs.Bool("update-stock", false, "deduct imported quantities from product stock")
+	dryRun := fs.Bool("dry-run", false, "validate and report without saving")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("-f is required")
+	}
+	if *userID == 0 {
+		return fmt.Errorf("-user is required")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	transactionService := service.NewTransactionService(db)
+	result, err := transactionService.ImportOrdersFrom(*file, f, dto.OrderImportOptions{
+		UserID:      *userID,
+		Date:        *date,
+		UpdateStock: *updateStock,
+		DryRun:      *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, row := range result.Rows {
+		if len(row.Errors) > 0 {
+			fmt.Printf("row %d (order %s): %s\n", row.Row, row.OrderID, strings.Join(row.Errors, "; "))
+		}
+	}
+	fmt.Printf("orders: %d created, %d skipped (already imported), %d total\n",
+		result.OrdersCreated, result.OrdersSkipped, result.Orders)
+	fmt.Printf("rows: %d created, %d failed\n", result.Created, result.Failed)
+	fmt.Printf("customers: %d created, %d matched\n", result.CustomersCreated, result.CustomersMatched)
+	fmt.Printf("products: %d created, %d matched\n", result.ProductsCreated, result.ProductsMatched)
+	if *dryRun {
+		fmt.Println("dry run: no changes applied")
+	}
+	return nil
+}
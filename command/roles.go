@@ -0,0 +1,80 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go-admin/dto"
+	"go-admin/service"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+func runRoles(db *gorm.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	roleService := service.NewRoleService(db)
+
+	switch args[0] {
+	case "export":
+		fs := flag.NewFlagSet("roles export", flag.ContinueOnError)
+		output := fs.String("o", "", "output file (default stdout)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		doc, err := roleService.ExportRoles()
+		if err != nil {
+			return err
+		}
+		out, err := dto.EncodeRoleDocument(doc)
+		if err != nil {
+			return err
+		}
+
+		if *output == "" {
+			_, err = os.Stdout.Write(out)
+			return err
+		}
+		return os.WriteFile(*output, out, 0644)
+
+	case "import":
+		fs := flag.NewFlagSet("roles import", flag.ContinueOnError)
+		file := fs.String("f", "", "YAML file to import")
+		dryRun := fs.Bool("dry-run", false, "show changes without applying them")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *file == "" {
+			return fmt.Errorf("-f is required")
+		}
+
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		doc, err := dto.DecodeRoleDocument(data)
+		if err != nil {
+			return err
+		}
+
+		changes, err := roleService.ImportRoles(doc, *dryRun)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			fmt.Println(change.String())
+		}
+		if *dryRun {
+			fmt.Println("dry run: no changes applied")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown roles command %q\n%s", args[0], usage)
+	}
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"go-admin/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditController struct {
+	service *service.AuditService
+}
+
+func NewAuditController(service *service.AuditService) *AuditController {
+	return &AuditController{service: service}
+}
+
+func (c *AuditController) AllEvents(ctx *fiber.Ctx) error {
+	query, err := listQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
+
+	result, err := c.service.GetEvents(query)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"Code":   200,
+		"Status": "OK",
+		"Data":   result["data"],
+		"Meta":   result["meta"],
+	})
+}
+
+func (c *AuditController) ExportExcel(ctx *fiber.Ctx) error {
+	query, err := listQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid query",
+			"error":   err.Error(),
+		})
+	}
+
+	file, err := c.service.ExportExcel(query)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Failed to generate Excel",
+			"error":   err.Error(),
+		})
+	}
+
+	ctx.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Set("Content-Disposition", "attachment; filename=audit_log.xlsx")
+
+	if _, err := file.WriteTo(ctx.Response().BodyWriter()); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	return nil
+}
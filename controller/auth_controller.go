@@ -1,8 +1,8 @@
 package controller
 
 import (
-	"fmt"
 	"github.com/gofiber/fiber/v2"
+	"go-admin/dto"
 	"go-admin/service"
 	"go-admin/util"
 	"time"
@@ -81,7 +81,7 @@ func User(c *fiber.Ctx) error {
 	}
 
 	authService := service.NewAuthService()
-	user, err := authService.GetUser(id)
+	profile, err := authService.GetProfile(id)
 	if err != nil {
 		return c.JSON(fiber.Map{
 			"Code":   200,
@@ -90,15 +90,10 @@ func User(c *fiber.Ctx) error {
 		})
 	}
 
-	fmt.Printf("User Role: %+v\n", user.Role)
-	if user.Role.Permissions != nil {
-		fmt.Printf("Permissions: %d items\n", len(user.Role.Permissions))
-	}
-
 	return c.JSON(fiber.Map{
 		"Code":   200,
 		"Status": "OK",
-		"Data":   user,
+		"Data":   profile,
 	})
 }
 
@@ -131,7 +126,7 @@ func UpdateInfo(c *fiber.Ctx) error {
 	id, _ := util.ParseJwt(cookie)
 
 	authService := service.NewAuthService()
-	user, err := authService.UpdateUserInfo(id, data)
+	user, err := authService.WithContext(c.UserContext()).UpdateUserInfo(id, data)
 	if err != nil {
 		return c.JSON(fiber.Map{
 			"Code":   200,
@@ -161,7 +156,7 @@ func UpdatePassword(c *fiber.Ctx) error {
 	id, _ := util.ParseJwt(cookie)
 
 	authService := service.NewAuthService()
-	if err := authService.UpdatePassword(id, data); err != nil {
+	if err := authService.WithContext(c.UserContext()).UpdatePassword(id, data); err != nil {
 		return c.JSON(fiber.Map{
 			"Code":   200,
 			"Status": "OK",
@@ -174,4 +169,34 @@ func UpdatePassword(c *fiber.Ctx) error {
 		"Status": "OK",
 		"Data":   fiber.Map{"message": "password updated"},
 	})
-}
\ No newline at end of file
+}
+
+func UpdatePreferences(c *fiber.Ctx) error {
+	var prefs dto.UserPreferences
+	if err := c.BodyParser(&prefs); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
+
+	cookie := c.Cookies("jwt")
+	id, _ := util.ParseJwt(cookie)
+
+	authService := service.NewAuthService()
+	updated, err := authService.WithContext(c.UserContext()).UpdatePreferences(id, prefs)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"Code":   200,
+		"Status": "OK",
+		"Data":   updated,
+	})
+}
@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"errors"
+	"go-admin/dto"
+	"go-admin/service"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CategoryController struct {
+	service *service.CategoryService
+}
+
+func NewCategoryController(service *service.CategoryService) *CategoryController {
+	return &CategoryController{service: service}
+}
+
+func (c *CategoryController) AllCategories(ctx *fiber.Ctx) error {
+	categories, err := c.service.GetCategoryTree()
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch categories",
+		})
+	}
+	return ctx.JSON(categories)
+}
+
+func (c *CategoryController) CreateCategory(ctx *fiber.Ctx) error {
+	var request dto.CategoryRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	category, err := c.service.WithContext(ctx.UserContext()).CreateCategory(request)
+	if err != nil {
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(category)
+}
+
+func (c *CategoryController) UpdateCategory(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	var request dto.CategoryRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	category, err := c.service.WithContext(ctx.UserContext()).UpdateCategory(uint(id), request)
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.JSON(category)
+}
+
+func (c *CategoryController) DeleteCategory(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	if err := c.service.WithContext(ctx.UserContext()).DeleteCategory(uint(id)); err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete category",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{"message": "Category deleted successfully"})
+}
+
+func (c *CategoryController) AllTags(ctx *fiber.Ctx) error {
+	tags, err := c.service.GetTags()
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch tags",
+		})
+	}
+	return ctx.JSON(tags)
+}
+
+func (c *CategoryController) CreateTag(ctx *fiber.Ctx) error {
+	var request dto.TagRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	tag, err := c.service.WithContext(ctx.UserContext()).CreateTag(request)
+	if err != nil {
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(tag)
+}
+
+func (c *CategoryController) UpdateTag(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tag ID",
+		})
+	}
+
+	var request dto.TagRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	tag, err := c.service.WithContext(ctx.UserContext()).UpdateTag(uint(id), request)
+	if err != nil {
+		if errors.Is(err, service.ErrTagNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.JSON(tag)
+}
+
+func (c *CategoryController) DeleteTag(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tag ID",
+		})
+	}
+
+	if err := c.service.WithContext(ctx.UserContext()).DeleteTag(uint(id)); err != nil {
+		if errors.Is(err, service.ErrTagNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete tag",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{"message": "Tag deleted successfully"})
+}
@@ -1,10 +1,12 @@
 package controller
 
 import (
+	"errors"
+	"go-admin/dto"
+	"go-admin/middlewares"
 	"go-admin/models"
 	"go-admin/service"
 	"net/http"
-	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -27,10 +29,30 @@ func (c *CustomerController) DropdownCustomers(ctx *fiber.Ctx) error {
 	return ctx.JSON(customers)
 }
 
+func (c *CustomerController) LookupCustomers(ctx *fiber.Ctx) error {
+	customers, err := c.service.LookupCustomers(ctx.Query("q"), ctx.QueryInt("limit", 10))
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to lookup customers",
+		})
+	}
+	return ctx.JSON(customers)
+}
+
 func (c *CustomerController) AllCustomers(ctx *fiber.Ctx) error {
+	query, err := listQuery(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
-	page, _ := strconv.Atoi(ctx.Query("page", "1"))
-	result := c.service.AllCustomers(page)
+	result, err := c.service.AllCustomers(query)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
 	return ctx.JSON(fiber.Map{
 		"Code":   200,
@@ -48,7 +70,7 @@ func (c *CustomerController) CreateCustomer(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if err := c.service.CreateCustomer(&customer); err != nil {
+	if err := c.service.WithContext(ctx.UserContext()).CreateCustomer(&customer); err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create customer",
 		})
@@ -90,7 +112,7 @@ func (c *CustomerController) UpdateCustomer(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if err := c.service.UpdateCustomer(uint(id), &updatedCustomer); err != nil {
+	if err := c.service.WithContext(ctx.UserContext()).UpdateCustomer(uint(id), &updatedCustomer); err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update customer",
 		})
@@ -107,7 +129,17 @@ func (c *CustomerController) DeleteCustomer(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if err := c.service.DeleteCustomer(uint(id)); err != nil {
+	if err := c.service.WithContext(ctx.UserContext()).DeleteCustomer(uint(id)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrCustomerNotFound):
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Customer not found",
+			})
+		case errors.Is(err, service.ErrCustomerHasReceivables), errors.Is(err, service.ErrCustomerHasHistory):
+			return ctx.Status(http.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to delete customer",
 		})
@@ -115,3 +147,123 @@ func (c *CustomerController) DeleteCustomer(ctx *fiber.Ctx) error {
 
 	return ctx.JSON(fiber.Map{"message": "Customer deleted successfully"})
 }
+
+func (c *CustomerController) CustomerTransactions(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid customer ID",
+		})
+	}
+
+	query, err := listQuery(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	scope, err := middlewares.GetDataScope(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	result, err := c.service.CustomerTransactions(uint(id), scope, query)
+	if err != nil {
+		if errors.Is(err, service.ErrCustomerNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Customer not found",
+			})
+		}
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if !middlewares.CanViewCost(ctx) {
+		transactions := result["data"].([]models.Transaction)
+		for i := range transactions {
+			transactions[i].HideCost()
+		}
+	}
+
+	return ctx.JSON(result)
+}
+
+func (c *CustomerController) CustomerSummary(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid customer ID",
+		})
+	}
+
+	scope, err := middlewares.GetDataScope(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	summary, err := c.service.CustomerSummary(uint(id), scope)
+	if err != nil {
+		if errors.Is(err, service.ErrCustomerNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Customer not found",
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load customer summary",
+		})
+	}
+
+	return ctx.JSON(summary)
+}
+
+func (c *CustomerController) FindDuplicates(ctx *fiber.Ctx) error {
+	duplicates, err := c.service.FindDuplicates(ctx.QueryInt("limit", 100))
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to find duplicate customers",
+		})
+	}
+	return ctx.JSON(duplicates)
+}
+
+// MergeCustomer menggabungkan customer duplicate_id ke customer :id.
+func (c *CustomerController) MergeCustomer(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid customer ID",
+		})
+	}
+
+	var request dto.CustomerMergeRequest
+	if err := ctx.BodyParser(&request); err != nil || request.DuplicateID == 0 {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "duplicate_id is required",
+		})
+	}
+
+	result, err := c.service.WithContext(ctx.UserContext()).MergeCustomers(uint(id), request.DuplicateID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCustomerNotFound):
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Customer not found",
+			})
+		case errors.Is(err, service.ErrMergeSameCustomer):
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to merge customers",
+		})
+	}
+
+	return ctx.JSON(result)
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"go-admin/middlewares"
 	"go-admin/service"
 )
 
@@ -14,7 +15,14 @@ func NewDashboardController(service *service.DashboardService) *DashboardControl
 }
 
 func (dc *DashboardController) GetDashboard(c *fiber.Ctx) error {
-	data, err := dc.service.GetDashboardData()
+	scope, err := middlewares.GetDataScope(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	data, err := dc.service.GetDashboardData(scope, middlewares.CanViewCost(c))
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Failed to fetch dashboard data",
@@ -0,0 +1,17 @@
+package controller
+
+import (
+	"go-admin/models"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// listQuery membaca filter, sort dan paging dari query string request.
+func listQuery(ctx *fiber.Ctx) (models.ListQuery, error) {
+	values, err := url.ParseQuery(string(ctx.Request().URI().QueryString()))
+	if err != nil {
+		return models.ListQuery{}, err
+	}
+	return models.ParseListQuery(values)
+}
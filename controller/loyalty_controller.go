@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"errors"
+	"go-admin/models"
+	"go-admin/service"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type LoyaltyController struct {
+	service *service.LoyaltyService
+}
+
+func NewLoyaltyController(service *service.LoyaltyService) *LoyaltyController {
+	return &LoyaltyController{service: service}
+}
+
+func (c *LoyaltyController) AllRules(ctx *fiber.Ctx) error {
+	rules, err := c.service.GetRules()
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch loyalty rules",
+		})
+	}
+	return ctx.JSON(rules)
+}
+
+func (c *LoyaltyController) CreateRule(ctx *fiber.Ctx) error {
+	var rule models.LoyaltyRule
+	if err := ctx.BodyParser(&rule); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	rule.ID = 0
+
+	if err := c.service.WithContext(ctx.UserContext()).CreateRule(&rule); err != nil {
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(rule)
+}
+
+func (c *LoyaltyController) UpdateRule(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid rule ID",
+		})
+	}
+
+	var data models.LoyaltyRule
+	if err := ctx.BodyParser(&data); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	rule, err := c.service.WithContext(ctx.UserContext()).UpdateRule(uint(id), &data)
+	if err != nil {
+		if errors.Is(err, service.ErrLoyaltyRuleNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.JSON(rule)
+}
+
+func (c *LoyaltyController) DeleteRule(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid rule ID",
+		})
+	}
+
+	if err := c.service.WithContext(ctx.UserContext()).DeleteRule(uint(id)); err != nil {
+		if errors.Is(err, service.ErrLoyaltyRuleNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete loyalty rule",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{"message": "Loyalty rule deleted successfully"})
+}
+
+func (c *LoyaltyController) CustomerPoints(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid customer ID",
+		})
+	}
+
+	balance, err := c.service.WithContext(ctx.UserContext()).Balance(uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrCustomerNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Customer not found",
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load points balance",
+		})
+	}
+
+	return ctx.JSON(balance)
+}
+
+func (c *LoyaltyController) CustomerPointsHistory(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid customer ID",
+		})
+	}
+
+	query, err := listQuery(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	result, err := c.service.History(uint(id), query)
+	if err != nil {
+		if errors.Is(err, service.ErrCustomerNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Customer not found",
+			})
+		}
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.JSON(result)
+}
@@ -3,9 +3,12 @@ package controller
 import (
 	"errors"
 	"go-admin/dto"
+	"go-admin/middlewares"
+	"go-admin/models"
 	"go-admin/service"
 	"mime/multipart"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -25,12 +28,11 @@ func (c *ProductController) Create(ctx *fiber.Ctx) error {
 		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid form data"})
 	}
 
-	// Ambil file
-	files := form.File["img_url"]
-	if len(files) == 0 {
-		return ctx.Status(400).JSON(fiber.Map{"error": "Image is required"})
-	}
-	file := files[0]
+	// Ambil file: img_url (lama) dan images boleh berisi beberapa gambar,
+	// gambar pertama menjadi gambar utama
+	var files []*multipart.FileHeader
+	files = append(files, form.File["img_url"]...)
+	files = append(files, form.File["images"]...)
 
 	// Ambil data text
 	title := form.Value["title"]
@@ -59,19 +61,39 @@ func (c *ProductController) Create(ctx *fiber.Ctx) error {
 		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid stock format"})
 	}
 
+	categoryID, tags, err := productCategoryAndTags(form)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	req := dto.ProductRequest{
 		Title:       title[0],
 		Description: description[0],
 		Price:       price,
 		SellPrice:   sellPrice,
 		Stock:       stock,
+		CategoryID:  categoryID,
+		Tags:        tags,
 	}
 
-	product, err := c.service.Create(file, req)
+	product, err := c.service.WithContext(ctx.UserContext()).Create(files, req)
 	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, service.ErrBarcodeInUse) {
+			return ctx.Status(409).JSON(fiber.Map{"error": err.Error()})
+		}
+		if status := imageUploadStatus(err); status != 0 {
+			return ctx.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
 		return ctx.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if !middlewares.CanViewCost(ctx) {
+		product.HideCost()
+	}
+
 	return ctx.Status(201).JSON(product)
 }
 
@@ -119,22 +141,39 @@ func (c *ProductController) Update(ctx *fiber.Ctx) error {
 		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid price format"})
 	}
 
+	categoryID, tags, err := productCategoryAndTags(form)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	req := dto.ProductRequest{
 		Title:       title[0],
 		Description: description[0],
 		Price:       price,
 		SellPrice:   sellPrice,
 		Stock:       stock,
+		CategoryID:  categoryID,
+		Tags:        tags,
 	}
 
-	product, err := c.service.Update(uint(id), file, req)
+	product, err := c.service.WithContext(ctx.UserContext()).Update(uint(id), file, req)
 	if err != nil {
 		if errors.Is(err, errors.New("product not found")) {
 			return ctx.Status(404).JSON(fiber.Map{"error": "Product not found"})
 		}
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if status := imageUploadStatus(err); status != 0 {
+			return ctx.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
 		return ctx.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if !middlewares.CanViewCost(ctx) {
+		product.HideCost()
+	}
+
 	return ctx.JSON(product)
 }
 
@@ -146,7 +185,7 @@ func (c *ProductController) Delete(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if err := c.service.Delete(uint(id)); err != nil {
+	if err := c.service.WithContext(ctx.UserContext()).Delete(uint(id)); err != nil {
 		if errors.Is(err, errors.New("product not found")) {
 			return ctx.Status(404).JSON(fiber.Map{"error": "Product not found"})
 		}
@@ -172,41 +211,72 @@ func (c *ProductController) GetByID(ctx *fiber.Ctx) error {
 		return ctx.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if !middlewares.CanViewCost(ctx) {
+		product.HideCost()
+	}
+
 	return ctx.JSON(product)
 }
 
 func (c *ProductController) GetAll(ctx *fiber.Ctx) error {
-	page, _ := strconv.Atoi(ctx.Query("page", "1"))
-	limit, _ := strconv.Atoi(ctx.Query("limit", "5"))
+	query, err := listQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 
-	// Parse request body untuk mendapatkan title
+	// Kompatibilitas: ?limit= dan body {"title": ...} dari versi sebelumnya
+	if query.PerPage == 0 {
+		query.PerPage = ctx.QueryInt("limit")
+	}
 	var requestBody struct {
 		Title string `json:"title"`
 	}
-
-	if err := ctx.BodyParser(&requestBody); err != nil {
-		// Jika parsing gagal, anggap tidak ada filter
-		requestBody.Title = ""
+	if err := ctx.BodyParser(&requestBody); err == nil && requestBody.Title != "" {
+		query.Filters = append(query.Filters, models.ListFilter{Field: "title", Op: "like", Value: requestBody.Title})
 	}
 
-	products, total, err := c.service.GetAll(page, limit, requestBody.Title)
+	products, meta, err := c.service.GetAll(query)
 	if err != nil {
-		return ctx.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Hitung last_page
-	lastPage := total / int64(limit)
-	if total%int64(limit) != 0 {
-		lastPage++
+	if !middlewares.CanViewCost(ctx) {
+		for i := range products {
+			products[i].HideCost()
+		}
 	}
 
 	return ctx.JSON(fiber.Map{
 		"data": products,
-		"meta": fiber.Map{
-			"total":     total,
-			"page":      page,
-			"limit":     limit,
-			"last_page": lastPage,
-		},
+		"meta": meta,
 	})
 }
+
+// productCategoryAndTags membaca field opsional category_id dan tags dari
+// form. Tags boleh dikirim berulang atau dipisah koma; tanpa field tags,
+// tag produk tidak diubah.
+func productCategoryAndTags(form *multipart.Form) (*uint, []string, error) {
+	var categoryID *uint
+	if values := form.Value["category_id"]; len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+		id, err := strconv.ParseUint(strings.TrimSpace(values[0]), 10, 32)
+		if err != nil {
+			return nil, nil, errors.New("invalid category_id format")
+		}
+		value := uint(id)
+		categoryID = &value
+	}
+
+	var tags []string
+	if values, ok := form.Value["tags"]; ok {
+		tags = []string{}
+		for _, value := range values {
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+		}
+	}
+
+	return categoryID, tags, nil
+}
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"go-admin/service"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Image mengirim file gambar produk dari MinIO. Query: size (thumbnail,
+// medium, original) dan image_id (default gambar utama).
+func (c *ProductController) Image(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	key, err := c.service.ImageKey(uint(id), uint(ctx.QueryInt("image_id")), ctx.Query("size"))
+	if err != nil {
+		return productImageError(ctx, err)
+	}
+
+	obj, info, err := c.service.OpenImage(key)
+	if err != nil {
+		if service.IsNotFound(err) {
+			return productImageError(ctx, service.ErrProductImageNotFound)
+		}
+		return ctx.Status(http.StatusBadGateway).JSON(fiber.Map{
+			"error": "Failed to load image",
+		})
+	}
+
+	// URL ini tetap sama saat gambar utama diganti, jadi browser harus
+	// selalu revalidasi lewat ETag sebelum memakai cache
+	etag := fmt.Sprintf("%q", info.ETag)
+	ctx.Set(fiber.HeaderETag, etag)
+	ctx.Set(fiber.HeaderCacheControl, "private, no-cache")
+	ctx.Set(fiber.HeaderLastModified, info.LastModified.UTC().Format(http.TimeFormat))
+	if etagMatches(ctx.Get(fiber.HeaderIfNoneMatch), etag) {
+		obj.Close()
+		return ctx.SendStatus(http.StatusNotModified)
+	}
+
+	if info.ContentType != "" {
+		ctx.Set(fiber.HeaderContentType, info.ContentType)
+	}
+	// SendStream menutup obj setelah response selesai dikirim
+	return ctx.SendStream(obj, int(info.Size))
+}
+
+// etagMatches membandingkan header If-None-Match dengan etag memakai weak
+// comparison (RFC 7232): header boleh berisi beberapa etag dipisah koma,
+// awalan W/ diabaikan, dan "*" cocok dengan etag apa pun.
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ProductController) GetImages(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	images, err := c.service.GetImages(uint(id))
+	if err != nil {
+		return productImageError(ctx, err)
+	}
+
+	return ctx.JSON(images)
+}
+
+// AddImages menerima satu atau beberapa file di field "images".
+func (c *ProductController) AddImages(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid form data",
+		})
+	}
+	files := form.File["images"]
+	if len(files) == 0 {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Image is required",
+		})
+	}
+
+	images, err := c.service.WithContext(ctx.UserContext()).AddImages(uint(id), files)
+	if err != nil {
+		return productImageError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(images)
+}
+
+func (c *ProductController) ReorderImages(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	var request struct {
+		IDs []uint `json:"ids"`
+	}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	images, err := c.service.WithContext(ctx.UserContext()).ReorderImages(uint(id), request.IDs)
+	if err != nil {
+		return productImageError(ctx, err)
+	}
+
+	return ctx.JSON(images)
+}
+
+func (c *ProductController) SetPrimaryImage(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+	imageID, err := ctx.ParamsInt("imageId")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid image ID",
+		})
+	}
+
+	images, err := c.service.WithContext(ctx.UserContext()).SetPrimaryImage(uint(id), uint(imageID))
+	if err != nil {
+		return productImageError(ctx, err)
+	}
+
+	return ctx.JSON(images)
+}
+
+func (c *ProductController) DeleteImage(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+	imageID, err := ctx.ParamsInt("imageId")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid image ID",
+		})
+	}
+
+	if err := c.service.WithContext(ctx.UserContext()).DeleteImage(uint(id), uint(imageID)); err != nil {
+		return productImageError(ctx, err)
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func productImageError(ctx *fiber.Ctx, err error) error {
+	status := http.StatusUnprocessableEntity
+	if errors.Is(err, service.ErrProductNotFound) || errors.Is(err, service.ErrProductImageNotFound) {
+		status = http.StatusNotFound
+	} else if imageStatus := imageUploadStatus(err); imageStatus != 0 {
+		status = imageStatus
+	}
+	return ctx.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// imageUploadStatus mengembalikan status 4xx untuk error validasi upload
+// gambar, atau 0 jika err bukan error validasi.
+func imageUploadStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrImageTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUnsupportedImage):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrInvalidImage):
+		return http.StatusBadRequest
+	}
+	return 0
+}
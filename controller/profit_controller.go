@@ -4,6 +4,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"go-admin/dto"
+	"go-admin/middlewares"
 	"go-admin/service"
 )
 
@@ -36,7 +37,14 @@ func (c *ProfitController) FilterProfit(ctx *fiber.Ctx) error {
 		})
 	}
 
-	profits, total_profit, err := c.service.FilterProfits(req.StartDate, req.EndDate)
+	scope, err := middlewares.GetDataScope(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	profits, total_profit, err := c.service.FilterProfits(scope, req.StartDate, req.EndDate)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to get sales",
@@ -61,7 +69,14 @@ func (c *ProfitController) ExportExcel(ctx *fiber.Ctx) error {
 		})
 	}
 
-	file, err := c.service.ExportExcel(req.StartDate, req.EndDate)
+	scope, err := middlewares.GetDataScope(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	file, err := c.service.ExportExcel(scope, req.StartDate, req.EndDate)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to generate Excel",
@@ -91,7 +106,14 @@ func (c *ProfitController) ExportPDF(ctx *fiber.Ctx) error {
 		})
 	}
 
-	pdfBytes, err := c.service.ExportPDF(req.StartDate, req.EndDate)
+	scope, err := middlewares.GetDataScope(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	pdfBytes, err := c.service.ExportPDF(scope, req.StartDate, req.EndDate)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to generate PDF",
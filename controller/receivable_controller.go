@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"errors"
+	"go-admin/dto"
+	"go-admin/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReceivableController struct {
+	service *service.ReceivableService
+}
+
+func NewReceivableController(service *service.ReceivableService) *ReceivableController {
+	return &ReceivableController{service: service}
+}
+
+func (c *ReceivableController) AllReceivables(ctx *fiber.Ctx) error {
+	query, err := listQuery(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	result, err := c.service.GetReceivables(query)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.JSON(result)
+}
+
+func (c *ReceivableController) GetReceivable(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid receivable ID",
+		})
+	}
+
+	receivable, err := c.service.GetReceivable(uint(id))
+	if err != nil {
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+			"error": "Receivable not found",
+		})
+	}
+
+	return ctx.JSON(receivable)
+}
+
+func (c *ReceivableController) RecordPayment(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid receivable ID",
+		})
+	}
+
+	var request dto.ReceivablePaymentRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	userID, _ := strconv.Atoi(ctx.Locals("userID").(string))
+
+	receivable, err := c.service.WithContext(ctx.UserContext()).RecordPayment(uint(id), uint(userID), request)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		switch {
+		case errors.Is(err, service.ErrReceivableNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrReceivableSettled):
+			status = http.StatusConflict
+		}
+		return ctx.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.JSON(receivable)
+}
+
+func (c *ReceivableController) Aging(ctx *fiber.Ctx) error {
+	report, err := c.service.Aging()
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build aging report",
+		})
+	}
+	return ctx.JSON(report)
+}
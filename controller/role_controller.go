@@ -3,6 +3,7 @@ package controller
 import (
 	"errors"
 	"github.com/gofiber/fiber/v2"
+	"go-admin/dto"
 	"go-admin/service"
 	"gorm.io/gorm"
 	"strconv"
@@ -44,7 +45,7 @@ func (c *RoleController) CreateRole(ctx *fiber.Ctx) error {
 		})
 	}
 
-	role, err := c.service.CreateRole(roleDto)
+	role, err := c.service.WithContext(ctx.UserContext()).CreateRole(roleDto)
 
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -67,8 +68,8 @@ func (c *RoleController) GetRole(ctx *fiber.Ctx) error {
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ctx.Status(401).JSON(fiber.Map{
-				"Code":   401,
+			return ctx.Status(404).JSON(fiber.Map{
+				"Code":   404,
 				"Status": "id not found",
 				"Data":   nil,
 			})
@@ -106,7 +107,7 @@ func (c *RoleController) UpdateRole(ctx *fiber.Ctx) error {
 		})
 	}
 
-	updatedRole, err := c.service.UpdateRole(uint(id), roleDto)
+	updatedRole, err := c.service.WithContext(ctx.UserContext()).UpdateRole(uint(id), roleDto)
 
 	if err != nil {
 		if err.Error() == "role not found" {
@@ -132,7 +133,7 @@ func (c *RoleController) UpdateRole(ctx *fiber.Ctx) error {
 
 func (c *RoleController) DeleteRole(ctx *fiber.Ctx) error {
 	id, _ := strconv.Atoi(ctx.Params("id"))
-	err := c.service.DeleteRole(uint(id))
+	err := c.service.WithContext(ctx.UserContext()).DeleteRole(uint(id))
 
 	if err != nil {
 		if err.Error() == "role not found" {
@@ -155,3 +156,60 @@ func (c *RoleController) DeleteRole(ctx *fiber.Ctx) error {
 		"Data":   nil,
 	})
 }
+
+func (c *RoleController) ExportRoles(ctx *fiber.Ctx) error {
+	doc, err := c.service.ExportRoles()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"Code":   500,
+			"Status": "Internal Server Error",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
+
+	out, err := dto.EncodeRoleDocument(doc)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"Code":   500,
+			"Status": "Internal Server Error",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
+
+	ctx.Set("Content-Type", "application/x-yaml")
+	ctx.Set("Content-Disposition", "attachment; filename=roles.yaml")
+
+	return ctx.Send(out)
+}
+
+// ImportRoles menerima dokumen YAML di body request. Gunakan ?dry_run=true
+// untuk melihat perbedaan tanpa menyimpan perubahan.
+func (c *RoleController) ImportRoles(ctx *fiber.Ctx) error {
+	doc, err := dto.DecodeRoleDocument(ctx.Body())
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
+
+	dryRun := ctx.QueryBool("dry_run", false)
+	changes, err := c.service.WithContext(ctx.UserContext()).ImportRoles(doc, dryRun)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"Code":   422,
+			"Status": "Unprocessable Entity",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"Code":   200,
+		"Status": "OK",
+		"Data": fiber.Map{
+			"dry_run": dryRun,
+			"changes": changes,
+		},
+	})
+}
@@ -1,9 +1,12 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"go-admin/dto"
+	"go-admin/middlewares"
 	"go-admin/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -39,22 +42,31 @@ func (c *TransactionController) SearchProduct(ctx *fiber.Ctx) error {
 		})
 	}
 
-	product, err := c.service.SearchProduct(barcode)
+	product, variant, err := c.service.SearchProduct(barcode)
 	if err != nil {
 		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
 			"message": "Product not found",
 		})
 	}
 
+	if !middlewares.CanViewCost(ctx) {
+		product.HideCost()
+		if variant != nil {
+			variant.HideCost()
+		}
+	}
+
 	return ctx.JSON(fiber.Map{
 		"success": true,
 		"data":    product,
+		"variant": variant,
 	})
 }
 
 func (c *TransactionController) AddToCart(ctx *fiber.Ctx) error {
 	var request struct {
 		ProductID uint    `json:"product_id"`
+		VariantID *uint   `json:"variant_id"`
 		Qty       float64 `json:"qty"`
 	}
 
@@ -69,7 +81,12 @@ func (c *TransactionController) AddToCart(ctx *fiber.Ctx) error {
 		return err
 	}
 
-	if err := c.service.AddToCart(userID, request.ProductID, request.Qty); err != nil {
+	if err := c.service.WithContext(ctx.UserContext()).AddToCart(userID, request.ProductID, request.VariantID, request.Qty); err != nil {
+		if errors.Is(err, service.ErrVariantNotFound) || errors.Is(err, service.ErrVariantRequired) {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to add to cart",
 		})
@@ -102,7 +119,7 @@ func (c *TransactionController) DestroyCart(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if err := c.service.DestroyCart(request.CartID); err != nil {
+	if err := c.service.WithContext(ctx.UserContext()).DestroyCart(request.CartID); err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to remove from cart",
 		})
@@ -126,6 +143,12 @@ func (c *TransactionController) GetCart(ctx *fiber.Ctx) error {
 		})
 	}
 
+	if !middlewares.CanViewCost(ctx) {
+		for i := range carts {
+			carts[i].HideCost()
+		}
+	}
+
 	return ctx.JSON(fiber.Map{
 		"success": true,
 		"data": fiber.Map{
@@ -135,13 +158,68 @@ func (c *TransactionController) GetCart(ctx *fiber.Ctx) error {
 	})
 }
 
-func (c *TransactionController) PayOrder(ctx *fiber.Ctx) error {
-	var request struct {
-		CustomerID uint    `json:"customer_id"`
-		Discount   float64 `json:"discount"`
-		Cash       float64 `json:"cash"`
+func (c *TransactionController) ListCarts(ctx *fiber.Ctx) error {
+	query, err := listQuery(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	scope, err := middlewares.GetDataScope(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
 	}
 
+	carts, meta, err := c.service.ListCarts(scope, query)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if !middlewares.CanViewCost(ctx) {
+		for i := range carts {
+			carts[i].HideCost()
+		}
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data": carts,
+		"meta": meta,
+	})
+}
+
+func (c *TransactionController) ListTransactions(ctx *fiber.Ctx) error {
+	query, err := listQuery(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	scope, err := middlewares.GetDataScope(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	result, err := c.service.ListTransactions(scope, query)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(result)
+}
+
+func (c *TransactionController) PayOrder(ctx *fiber.Ctx) error {
+	var request dto.PayOrderRequest
+
 	if err := ctx.BodyParser(&request); err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
@@ -159,7 +237,7 @@ func (c *TransactionController) PayOrder(ctx *fiber.Ctx) error {
 		})
 	}
 
-	transaction, err := c.service.PayOrder(userID, request.CustomerID, request.Discount, request.Cash)
+	transaction, err := c.service.WithContext(ctx.UserContext()).PayOrder(userID, request)
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -168,9 +246,101 @@ func (c *TransactionController) PayOrder(ctx *fiber.Ctx) error {
 		})
 	}
 
+	if !middlewares.CanViewCost(ctx) {
+		transaction.HideCost()
+	}
+
 	return ctx.JSON(fiber.Map{
 		"success": true,
 		"message": "Payment successful",
 		"data":    transaction,
 	})
 }
+
+func (c *TransactionController) VoidTransaction(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid transaction ID",
+		})
+	}
+
+	var request dto.VoidTransactionRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request",
+			"error":   err.Error(),
+		})
+	}
+
+	userID, err := c.getUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	transaction, err := c.service.WithContext(ctx.UserContext()).VoidTransaction(uint(id), userID, request.Reason)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		switch {
+		case errors.Is(err, service.ErrTransactionNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrTransactionVoided), errors.Is(err, service.ErrReceivableHasPayments):
+			status = http.StatusConflict
+		}
+		return ctx.Status(status).JSON(fiber.Map{
+			"success": false,
+			"message": "Void failed",
+			"error":   err.Error(),
+		})
+	}
+
+	if !middlewares.CanViewCost(ctx) {
+		transaction.HideCost()
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Transaction voided",
+		"data":    transaction,
+	})
+}
+
+// ImportOrders mengimpor order lama dari file di field "file" (format
+// csv/orders.csv). Query: date (tanggal transaksi jika file tidak punya kolom
+// date), update_stock=true untuk mengurangi stok, dry_run=true untuk validasi.
+func (c *TransactionController) ImportOrders(ctx *fiber.Ctx) error {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "File is required",
+		})
+	}
+
+	userID, err := c.getUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := c.service.WithContext(ctx.UserContext()).ImportOrders(file, dto.OrderImportOptions{
+		UserID:      userID,
+		Date:        ctx.Query("date"),
+		UpdateStock: ctx.QueryBool("update_stock", false),
+		DryRun:      ctx.QueryBool("dry_run", false),
+	})
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Import failed",
+			"error":   err.Error(),
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": result.Failed == 0,
+		"message": "Import finished",
+		"data":    result,
+	})
+}
@@ -3,10 +3,10 @@ package controller
 import (
 	"errors"
 	"github.com/gofiber/fiber/v2"
-	"go-admin/middlewares"
 	"go-admin/models"
 	"go-admin/service"
 	"gorm.io/gorm"
+	"net/http"
 	"strconv"
 )
 
@@ -19,12 +19,23 @@ func NewUserController(service *service.UserService) *UserController {
 }
 
 func (c *UserController) AllUsers(ctx *fiber.Ctx) error {
-	if err := middlewares.IsAuthorized(ctx, "users"); err != nil {
-		return err
+	query, err := listQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
 	}
 
-	page, _ := strconv.Atoi(ctx.Query("page", "1"))
-	result := c.service.GetAllUsers(page)
+	result, err := c.service.GetAllUsers(query)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
 
 	return ctx.JSON(fiber.Map{
 		"Code":   200,
@@ -35,10 +46,6 @@ func (c *UserController) AllUsers(ctx *fiber.Ctx) error {
 }
 
 func (c *UserController) CreateUser(ctx *fiber.Ctx) error {
-	if err := middlewares.IsAuthorized(ctx, "users"); err != nil {
-		return err
-	}
-
 	var user models.User
 	if err := ctx.BodyParser(&user); err != nil {
 		return ctx.JSON(fiber.Map{
@@ -48,7 +55,21 @@ func (c *UserController) CreateUser(ctx *fiber.Ctx) error {
 		})
 	}
 
-	createdUser := c.service.CreateUser(&user)
+	createdUser, err := c.service.WithContext(ctx.UserContext()).CreateUser(&user)
+	if err != nil {
+		if errors.Is(err, service.ErrEmailInUse) || errors.Is(err, service.ErrEmailDeleted) {
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"Code":   409,
+				"Status": "Conflict",
+				"Data":   fiber.Map{"error": err.Error()},
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"Code":   500,
+			"Status": "Internal Server Error",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
 
 	return ctx.JSON(fiber.Map{
 		"Code":   200,
@@ -63,8 +84,8 @@ func (c *UserController) GetUser(ctx *fiber.Ctx) error {
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ctx.Status(401).JSON(fiber.Map{
-				"Code":   401,
+			return ctx.Status(404).JSON(fiber.Map{
+				"Code":   404,
 				"Status": "id not found",
 				"Data":   nil,
 			})
@@ -103,7 +124,7 @@ func (c *UserController) UpdateUser(ctx *fiber.Ctx) error {
 		})
 	}
 
-	updatedUser, err := c.service.UpdateUser(uint(id), &userData)
+	updatedUser, err := c.service.WithContext(ctx.UserContext()).UpdateUser(uint(id), &userData)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -113,11 +134,12 @@ func (c *UserController) UpdateUser(ctx *fiber.Ctx) error {
 			})
 		}
 
-		if err.Error() == "email already in use" {
+		if errors.Is(err, service.ErrEmailInUse) || errors.Is(err, service.ErrEmailDeleted) ||
+			errors.Is(err, service.ErrLastRoleAdmin) {
 			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"Code":   409,
 				"Status": "Conflict",
-				"Data":   fiber.Map{"error": "Email already in use"},
+				"Data":   fiber.Map{"error": err.Error()},
 			})
 		}
 
@@ -137,16 +159,31 @@ func (c *UserController) UpdateUser(ctx *fiber.Ctx) error {
 
 func (c *UserController) DeleteUser(ctx *fiber.Ctx) error {
 	id, _ := strconv.Atoi(ctx.Params("id"))
-	err := c.service.DeleteUser(uint(id))
+	if ctx.Locals("userID") == strconv.Itoa(id) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": "You cannot delete your own account"},
+		})
+	}
+
+	err := c.service.WithContext(ctx.UserContext()).DeleteUser(uint(id))
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ctx.Status(401).JSON(fiber.Map{
-				"Code":   401,
+			return ctx.Status(404).JSON(fiber.Map{
+				"Code":   404,
 				"Status": "id not found",
 				"Data":   nil,
 			})
 		}
+		if errors.Is(err, service.ErrLastRoleAdmin) {
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"Code":   409,
+				"Status": "Conflict",
+				"Data":   fiber.Map{"error": err.Error()},
+			})
+		}
 		return ctx.Status(500).JSON(fiber.Map{
 			"Code":   500,
 			"Status": "Internal Server Error",
@@ -160,3 +197,220 @@ func (c *UserController) DeleteUser(ctx *fiber.Ctx) error {
 		"Data":   nil,
 	})
 }
+
+func (c *UserController) ActivateUser(ctx *fiber.Ctx) error {
+	return c.setStatus(ctx, models.UserStatusActive)
+}
+
+func (c *UserController) DeactivateUser(ctx *fiber.Ctx) error {
+	return c.setStatus(ctx, models.UserStatusInactive)
+}
+
+func (c *UserController) setStatus(ctx *fiber.Ctx, status string) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": "Invalid ID format"},
+		})
+	}
+
+	if status == models.UserStatusInactive && ctx.Locals("userID") == strconv.Itoa(id) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": "You cannot deactivate your own account"},
+		})
+	}
+
+	user, err := c.service.WithContext(ctx.UserContext()).SetStatus(uint(id), status)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"Code":   404,
+				"Status": "Not Found",
+				"Data":   fiber.Map{"error": "User not found"},
+			})
+		}
+		if errors.Is(err, service.ErrLastRoleAdmin) {
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"Code":   409,
+				"Status": "Conflict",
+				"Data":   fiber.Map{"error": err.Error()},
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"Code":   500,
+			"Status": "Internal Server Error",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"Code":   200,
+		"Status": "OK",
+		"Data":   user,
+	})
+}
+
+func (c *UserController) RestoreUser(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": "Invalid ID format"},
+		})
+	}
+
+	user, err := c.service.WithContext(ctx.UserContext()).RestoreUser(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"Code":   404,
+				"Status": "Not Found",
+				"Data":   fiber.Map{"error": "Deleted user not found"},
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"Code":   500,
+			"Status": "Internal Server Error",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"Code":   200,
+		"Status": "OK",
+		"Data":   user,
+	})
+}
+
+// ImportUsers menerima file CSV/XLSX di field "file". Gunakan ?dry_run=true
+// untuk validasi tanpa membuat user.
+func (c *UserController) ImportUsers(ctx *fiber.Ctx) error {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": "File is required"},
+		})
+	}
+
+	result, err := c.service.WithContext(ctx.UserContext()).ImportUsers(file, ctx.QueryBool("dry_run", false))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
+
+	if result.Failed > 0 {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"Code":   422,
+			"Status": "Unprocessable Entity",
+			"Data":   result,
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"Code":   200,
+		"Status": "OK",
+		"Data":   result,
+	})
+}
+
+func (c *UserController) UploadAvatar(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": "Invalid ID format"},
+		})
+	}
+	return c.uploadAvatar(ctx, uint(id))
+}
+
+func (c *UserController) DeleteAvatar(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": "Invalid ID format"},
+		})
+	}
+	return c.deleteAvatar(ctx, uint(id))
+}
+
+// UploadMyAvatar dan DeleteMyAvatar mengubah avatar user yang sedang login.
+func (c *UserController) UploadMyAvatar(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Locals("userID").(string))
+	return c.uploadAvatar(ctx, uint(id))
+}
+
+func (c *UserController) DeleteMyAvatar(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Locals("userID").(string))
+	return c.deleteAvatar(ctx, uint(id))
+}
+
+func (c *UserController) uploadAvatar(ctx *fiber.Ctx, id uint) error {
+	file, err := ctx.FormFile("avatar")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"Code":   400,
+			"Status": "Bad Request",
+			"Data":   fiber.Map{"error": "Avatar image is required"},
+		})
+	}
+
+	user, err := c.service.WithContext(ctx.UserContext()).UploadAvatar(id, file)
+	if err != nil {
+		return avatarError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"Code":   200,
+		"Status": "OK",
+		"Data":   user,
+	})
+}
+
+func (c *UserController) deleteAvatar(ctx *fiber.Ctx, id uint) error {
+	user, err := c.service.WithContext(ctx.UserContext()).DeleteAvatar(id)
+	if err != nil {
+		return avatarError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"Code":   200,
+		"Status": "OK",
+		"Data":   user,
+	})
+}
+
+func avatarError(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"Code":   404,
+			"Status": "Not Found",
+			"Data":   fiber.Map{"error": "User not found"},
+		})
+	}
+	if status := imageUploadStatus(err); status != 0 {
+		return ctx.Status(status).JSON(fiber.Map{
+			"Code":   status,
+			"Status": http.StatusText(status),
+			"Data":   fiber.Map{"error": err.Error()},
+		})
+	}
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"Code":   500,
+		"Status": "Internal Server Error",
+		"Data":   fiber.Map{"error": err.Error()},
+	})
+}
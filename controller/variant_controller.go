@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"errors"
+	"go-admin/dto"
+	"go-admin/middlewares"
+	"go-admin/service"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (c *ProductController) GetVariants(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	variants, err := c.service.GetVariants(uint(id))
+	if err != nil {
+		return variantError(ctx, err)
+	}
+
+	if !middlewares.CanViewCost(ctx) {
+		for i := range variants {
+			variants[i].HideCost()
+		}
+	}
+
+	return ctx.JSON(variants)
+}
+
+func (c *ProductController) SetOptions(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	var request dto.ProductOptionsRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	product, err := c.service.WithContext(ctx.UserContext()).SetOptions(uint(id), request.Options)
+	if err != nil {
+		return variantError(ctx, err)
+	}
+
+	if !middlewares.CanViewCost(ctx) {
+		product.HideCost()
+	}
+
+	return ctx.JSON(product)
+}
+
+func (c *ProductController) CreateVariant(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	var request dto.VariantRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	variant, err := c.service.WithContext(ctx.UserContext()).CreateVariant(uint(id), request)
+	if err != nil {
+		return variantError(ctx, err)
+	}
+
+	if !middlewares.CanViewCost(ctx) {
+		variant.HideCost()
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(variant)
+}
+
+func (c *ProductController) UpdateVariant(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+	variantID, err := ctx.ParamsInt("variantId")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid variant ID",
+		})
+	}
+
+	var request dto.VariantRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	variant, err := c.service.WithContext(ctx.UserContext()).UpdateVariant(uint(id), uint(variantID), request)
+	if err != nil {
+		return variantError(ctx, err)
+	}
+
+	if !middlewares.CanViewCost(ctx) {
+		variant.HideCost()
+	}
+
+	return ctx.JSON(variant)
+}
+
+func (c *ProductController) DeleteVariant(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+	variantID, err := ctx.ParamsInt("variantId")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid variant ID",
+		})
+	}
+
+	if err := c.service.WithContext(ctx.UserContext()).DeleteVariant(uint(id), uint(variantID)); err != nil {
+		return variantError(ctx, err)
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func variantError(ctx *fiber.Ctx, err error) error {
+	status := http.StatusUnprocessableEntity
+	if errors.Is(err, service.ErrProductNotFound) || errors.Is(err, service.ErrVariantNotFound) {
+		status = http.StatusNotFound
+	}
+	return ctx.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,301 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-admin/models"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const auditBeforeKey = "audit:before"
+
+// RegisterAuditCallbacks mencatat create, update dan delete pada model
+// yang mengimplementasikan models.Auditable ke tabel audit_events.
+// Pelaku diambil dari context (lihat models.WithAuditActor), jadi service
+// harus memakai db.WithContext agar actor, IP dan request ID ikut tercatat.
+func RegisterAuditCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+
+	// Setelah hook AfterCreate, supaya kolom yang diisi hook ikut tercatat
+	if err := callbacks.Create().After("gorm:after_create").Register("audit:after_create", auditAfterCreate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("audit:before_update", auditLoadBeforeUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("audit:after_update", auditAfterUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("audit:before_delete", auditLoadBeforeDelete); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Register("audit:after_delete", auditAfterDelete)
+}
+
+// RecordAudit mencatat event audit secara manual, untuk perubahan yang tidak
+// tertangkap callback seperti relasi many2many atau diskon transaksi.
+func RecordAudit(db *gorm.DB, action, entityType string, entityID uint, before, after interface{}) error {
+	event := models.AuditEvent{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+	}
+
+	if before != nil {
+		data, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		event.Before = data
+	}
+	if after != nil {
+		data, err := json.Marshal(after)
+		if err != nil {
+			return err
+		}
+		event.After = data
+	}
+
+	return saveAuditEvent(db, &event)
+}
+
+func saveAuditEvent(db *gorm.DB, event *models.AuditEvent) error {
+	if actor, ok := models.AuditActorFrom(db.Statement.Context); ok {
+		if actor.UserID != 0 {
+			actorID := actor.UserID
+			event.ActorID = &actorID
+		}
+		event.IP = actor.IP
+		event.RequestID = actor.RequestID
+	}
+
+	return db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Create(event).Error
+}
+
+func auditEntity(db *gorm.DB) (string, bool) {
+	if models.AuditSkipped(db) {
+		return "", false
+	}
+	if db.Statement.Schema == nil || db.Statement.Schema.PrioritizedPrimaryField == nil {
+		return "", false
+	}
+	auditable, ok := reflect.New(db.Statement.Schema.ModelType).Interface().(models.Auditable)
+	if !ok {
+		return "", false
+	}
+	return auditable.AuditEntity(), true
+}
+
+// auditPrimaryKeys mengambil primary key dari model yang sedang diproses.
+// Update/delete massal tanpa primary key tidak dicatat.
+func auditPrimaryKeys(db *gorm.DB) []uint {
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	value := reflect.Indirect(db.Statement.ReflectValue)
+
+	var ids []uint
+	collect := func(v reflect.Value) {
+		pk, zero := field.ValueOf(db.Statement.Context, v)
+		if zero {
+			return
+		}
+		if id, ok := toUint(pk); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			collect(reflect.Indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		collect(value)
+	}
+	return ids
+}
+
+func toUint(v interface{}) (uint, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return uint(rv.Uint()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint(rv.Int()), true
+	}
+	return 0, false
+}
+
+// auditSnapshot membaca baris terbaru dari database sebagai map JSON.
+func auditSnapshot(db *gorm.DB, id uint) map[string]interface{} {
+	record := reflect.New(db.Statement.Schema.ModelType).Interface()
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Unscoped().
+		First(record, id).Error
+	if err != nil {
+		return nil
+	}
+	return auditRecordSnapshot(db, record)
+}
+
+func auditRecordSnapshot(db *gorm.DB, record interface{}) map[string]interface{} {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil
+	}
+
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+
+	// Relasi tidak di-preload, jadi tidak ikut dicatat
+	for _, rel := range db.Statement.Schema.Relationships.Relations {
+		name := strings.Split(rel.Field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = rel.Field.Name
+		}
+		delete(snapshot, name)
+	}
+	return snapshot
+}
+
+func auditLoadBeforeUpdate(db *gorm.DB) {
+	auditLoadBefore(db, true)
+}
+
+func auditLoadBeforeDelete(db *gorm.DB) {
+	auditLoadBefore(db, false)
+}
+
+func auditLoadBefore(db *gorm.DB, update bool) {
+	if db.Error != nil {
+		return
+	}
+	if _, ok := auditEntity(db); !ok {
+		return
+	}
+
+	snapshots := make(map[uint]map[string]interface{})
+	ids := auditPrimaryKeys(db)
+	if len(ids) == 1 && auditModelLoaded(db, update) {
+		snapshots[ids[0]] = auditRecordSnapshot(db, db.Statement.ReflectValue.Addr().Interface())
+	} else {
+		for _, id := range ids {
+			snapshots[id] = auditSnapshot(db, id)
+		}
+	}
+	db.InstanceSet(auditBeforeKey, snapshots)
+}
+
+// auditModelLoaded menentukan apakah model yang diproses bisa langsung dipakai
+// sebagai snapshot "before" tanpa SELECT ulang: model harus hasil baca dari
+// database (created_at terisi) dan belum berisi nilai baru. Save(&model)
+// membawa nilai baru di struct yang sama, jadi untuk update tetap dibaca ulang.
+func auditModelLoaded(db *gorm.DB, update bool) bool {
+	value := db.Statement.ReflectValue
+	if value.Kind() != reflect.Struct || !value.CanAddr() {
+		return false
+	}
+	if update {
+		dest := reflect.ValueOf(db.Statement.Dest)
+		if dest.Kind() == reflect.Ptr && dest.Pointer() == value.Addr().Pointer() {
+			return false
+		}
+	}
+	createdAt := db.Statement.Schema.LookUpField("created_at")
+	if createdAt == nil {
+		return false
+	}
+	_, zero := createdAt.ValueOf(db.Statement.Context, value)
+	return !zero
+}
+
+func auditBefore(db *gorm.DB) map[uint]map[string]interface{} {
+	value, ok := db.InstanceGet(auditBeforeKey)
+	if !ok {
+		return nil
+	}
+	snapshots, _ := value.(map[uint]map[string]interface{})
+	return snapshots
+}
+
+func auditAfterCreate(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	entity, ok := auditEntity(db)
+	if !ok {
+		return
+	}
+
+	for _, id := range auditPrimaryKeys(db) {
+		after := auditSnapshot(db, id)
+		if err := RecordAudit(db, "create", entity, id, nil, after); err != nil {
+			db.AddError(fmt.Errorf("audit: %w", err))
+		}
+	}
+}
+
+func auditAfterUpdate(db *gorm.DB) {
+	if db.Error != nil || db.Statement.RowsAffected == 0 {
+		return
+	}
+	entity, ok := auditEntity(db)
+	if !ok {
+		return
+	}
+
+	for id, before := range auditBefore(db) {
+		after := auditSnapshot(db, id)
+		changedBefore, changedAfter := auditDiff(before, after)
+		if len(changedAfter) == 0 && len(changedBefore) == 0 {
+			continue
+		}
+		if err := RecordAudit(db, "update", entity, id, changedBefore, changedAfter); err != nil {
+			db.AddError(fmt.Errorf("audit: %w", err))
+		}
+	}
+}
+
+func auditAfterDelete(db *gorm.DB) {
+	if db.Error != nil || db.Statement.RowsAffected == 0 {
+		return
+	}
+	entity, ok := auditEntity(db)
+	if !ok {
+		return
+	}
+
+	for id, before := range auditBefore(db) {
+		if err := RecordAudit(db, "delete", entity, id, before, nil); err != nil {
+			db.AddError(fmt.Errorf("audit: %w", err))
+		}
+	}
+}
+
+// auditDiff mengembalikan hanya field yang berubah, tanpa updated_at.
+func auditDiff(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	changedBefore := make(map[string]interface{})
+	changedAfter := make(map[string]interface{})
+
+	for key, value := range after {
+		if key == "updated_at" {
+			continue
+		}
+		if old, ok := before[key]; !ok || !reflect.DeepEqual(old, value) {
+			changedBefore[key] = before[key]
+			changedAfter[key] = value
+		}
+	}
+	for key, old := range before {
+		if _, ok := after[key]; !ok && key != "updated_at" {
+			changedBefore[key] = old
+			changedAfter[key] = nil
+		}
+	}
+
+	return changedBefore, changedAfter
+}
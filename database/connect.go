@@ -18,7 +18,24 @@ func Connect() *gorm.DB {
 
 	DB = db
 
-	err = db.AutoMigrate(
+	if err := RegisterAuditCallbacks(db); err != nil {
+		panic("Could not register audit callbacks: " + err.Error())
+	}
+
+	if err := Migrate(db); err != nil {
+		panic("Migration failed: " + err.Error())
+	}
+
+	fmt.Println("Database connected successfully")
+	return db
+}
+
+func Migrate(db *gorm.DB) error {
+	if err := renameStorageColumns(db); err != nil {
+		return err
+	}
+
+	err := db.AutoMigrate(
 		&models.User{},
 		&models.Role{},
 		&models.Permission{},
@@ -28,11 +45,73 @@ func Connect() *gorm.DB {
 		&models.Transaction{},
 		&models.TransactionDetail{},
 		&models.Profit{},
+		&models.AuditEvent{},
+		&models.LoyaltyRule{},
+		&models.LoyaltyEntry{},
+		&models.Category{},
+		&models.Tag{},
+		&models.ProductVariant{},
+		&models.ProductImage{},
+		&models.Receivable{},
+		&models.ReceivablePayment{},
 	)
 	if err != nil {
-		panic("Migration failed: " + err.Error())
+		return err
 	}
 
-	fmt.Println("Database connected successfully")
-	return db
+	if err := createLookupIndexes(db); err != nil {
+		return err
+	}
+	if err := migrateStorageKeys(db); err != nil {
+		return err
+	}
+	if err := migrateProductImages(db); err != nil {
+		return err
+	}
+	if err := clearEmptyCustomerEmails(db); err != nil {
+		return err
+	}
+	if err := migrateLoyaltySources(db); err != nil {
+		return err
+	}
+
+	return SeedPermissions(db)
+}
+
+// clearEmptyCustomerEmails mengganti email customer yang kosong dengan NULL,
+// supaya lebih dari satu customer boleh tidak punya email.
+func clearEmptyCustomerEmails(db *gorm.DB) error {
+	return db.Exec("UPDATE customers SET email = NULL WHERE email = ''").Error
+}
+
+// migrateLoyaltySources mengisi source_entry_id entry expire lama dari
+// catatannya, supaya poin kedaluwarsa per entry bisa dijumlahkan.
+func migrateLoyaltySources(db *gorm.DB) error {
+	var entries []models.LoyaltyEntry
+	if err := db.Where("type = ? AND source_entry_id IS NULL", models.LoyaltyExpire).
+		Find(&entries).Error; err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		var sourceID uint
+		if _, err := fmt.Sscanf(entry.Note, "expired points from entry #%d", &sourceID); err != nil {
+			continue
+		}
+		if err := db.Model(&entry).UpdateColumn("source_entry_id", sourceID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateProductImages memindahkan gambar produk lama ke galeri sebagai
+// gambar utama. Produk lama belum punya thumbnail, jadi semua ukuran memakai
+// file asli.
+func migrateProductImages(db *gorm.DB) error {
+	return db.Exec(`INSERT INTO product_images
+		(product_id, position, is_primary, original_key, medium_key, thumbnail_key, created_at, updated_at)
+		SELECT id, 0, ?, image_key, image_key, image_key, created_at, updated_at FROM products
+		WHERE image_key <> '' AND NOT EXISTS
+			(SELECT 1 FROM product_images WHERE product_images.product_id = products.id)`, true).Error
 }
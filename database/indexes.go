@@ -0,0 +1,39 @@
+package database
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// lookupIndexes adalah index expression untuk pencarian prefix pada
+// typeahead customer (lihat CustomerService.LookupCustomers).
+var lookupIndexes = []struct {
+	name, table, expression string
+}{
+	{"idx_customers_name_lower", "customers", "LOWER(name)"},
+	{"idx_customers_email_lower", "customers", "LOWER(email)"},
+	{"idx_customers_phone_prefix", "customers", "phone"},
+}
+
+// createLookupIndexes membuat index yang tidak bisa dideklarasikan lewat tag
+// gorm. Di PostgreSQL index memakai text_pattern_ops supaya LIKE 'abc%'
+// tetap memakai index apa pun collation database-nya.
+func createLookupIndexes(db *gorm.DB) error {
+	opclass := ""
+	if db.Dialector.Name() == "postgres" {
+		opclass = " text_pattern_ops"
+	}
+
+	for _, index := range lookupIndexes {
+		expression := index.expression + opclass
+		if strings.Contains(index.expression, "(") {
+			expression = "(" + expression + ")"
+		}
+		sql := "CREATE INDEX IF NOT EXISTS " + index.name + " ON " + index.table + " (" + expression + ")"
+		if err := db.Exec(sql).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"go-admin/models"
+	"gorm.io/gorm"
+)
+
+// PermissionNames adalah daftar permission yang dipakai oleh routes.
+// Permission yang belum ada di database akan dibuat saat migrasi.
+var PermissionNames = []string{
+	"view_dashboard",
+	"view_users",
+	"edit_users",
+	"view_roles",
+	"edit_roles",
+	"view_products",
+	"edit_products",
+	"view_customers",
+	"edit_customers",
+	"edit_loyalty",
+	"view_receivables",
+	"edit_receivables",
+	"view_transactions",
+	"edit_transactions",
+	"void_transactions",
+	"import_transactions",
+	"view_sales",
+	"view_profit",
+	"view_cost",
+	"view_audit",
+}
+
+// permissionSources menentukan role mana yang otomatis mendapat permission
+// baru saat upgrade: role yang sudah punya salah satu permission sumber.
+// Selain itu, setiap permission baru diberikan ke role yang punya edit_roles
+// (admin), supaya upgrade tidak mengunci siapa pun dari fitur yang dulu
+// terbuka untuk semua user yang login.
+var permissionSources = map[string][]string{
+	"view_dashboard":   {"view_transactions"},
+	"view_sales":       {"view_transactions"},
+	"view_customers":   {"view_transactions"},
+	"edit_customers":   {"edit_transactions"},
+	"view_receivables": {"view_transactions"},
+	"view_profit":      {"edit_products"},
+	"view_cost":        {"edit_products"},
+}
+
+// SeedPermissions membuat permission yang belum ada. Permission yang baru
+// dibuat langsung diberikan ke role sesuai permissionSources; karena hanya
+// terjadi saat permission dibuat, hak akses yang kemudian dicabut admin
+// tidak diberikan ulang.
+func SeedPermissions(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, name := range PermissionNames {
+			var count int64
+			if err := tx.Model(&models.Permission{}).Where("name = ?", name).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+
+			permission := models.Permission{Name: name}
+			if err := tx.Create(&permission).Error; err != nil {
+				return err
+			}
+			sources := append([]string{"edit_roles"}, permissionSources[name]...)
+			if err := grantPermission(tx, permission.Id, sources); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// grantPermission memberikan permission ke semua role yang punya salah satu
+// permission sources.
+func grantPermission(tx *gorm.DB, permissionID uint, sources []string) error {
+	return tx.Exec(`INSERT INTO role_permissions (role_id, permission_id)
+		SELECT DISTINCT role_permissions.role_id, ? FROM role_permissions
+		JOIN permissions ON permissions.id = role_permissions.permission_id
+		WHERE permissions.name IN ? AND NOT EXISTS
+			(SELECT 1 FROM role_permissions existing
+			WHERE existing.role_id = role_permissions.role_id AND existing.permission_id = ?)`,
+		permissionID, sources, permissionID).Error
+}
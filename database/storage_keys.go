@@ -0,0 +1,60 @@
+package database
+
+import (
+	"go-admin/models"
+
+	"gorm.io/gorm"
+)
+
+// storageKeyColumns adalah kolom yang menyimpan key object MinIO, beserta
+// nama kolom lama yang dulu menyimpan url lengkap.
+var storageKeyColumns = []struct {
+	table, oldColumn, column string
+}{
+	{"products", "img_url", "image_key"},
+	{"product_images", "url", "original_key"},
+	{"product_images", "medium_url", "medium_key"},
+	{"product_images", "thumbnail_url", "thumbnail_key"},
+	{"users", "avatar_url", "avatar_key"},
+}
+
+// renameStorageColumns mengganti nama kolom url lama menjadi kolom key.
+// Harus dijalankan sebelum AutoMigrate supaya tidak terbentuk kolom baru
+// yang kosong.
+func renameStorageColumns(db *gorm.DB) error {
+	migrator := db.Migrator()
+	for _, c := range storageKeyColumns {
+		if !migrator.HasTable(c.table) ||
+			!migrator.HasColumn(c.table, c.oldColumn) ||
+			migrator.HasColumn(c.table, c.column) {
+			continue
+		}
+		if err := migrator.RenameColumn(c.table, c.oldColumn, c.column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateStorageKeys mengubah url lengkap yang tersimpan dari versi lama,
+// misalnya "http://localhost:9000/products/123.jpg", menjadi key object
+// "123.jpg".
+func migrateStorageKeys(db *gorm.DB) error {
+	for _, c := range storageKeyColumns {
+		var values []string
+		if err := db.Table(c.table).
+			Distinct(c.column).
+			Where(c.column+" LIKE ? OR "+c.column+" LIKE ?", "http://%", "https://%").
+			Pluck(c.column, &values).Error; err != nil {
+			return err
+		}
+
+		for _, value := range values {
+			if err := db.Exec("UPDATE "+c.table+" SET "+c.column+" = ? WHERE "+c.column+" = ?",
+				models.ObjectKey(value), value).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
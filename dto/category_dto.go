@@ -0,0 +1,10 @@
+package dto
+
+type CategoryRequest struct {
+	Name     string `json:"name"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+type TagRequest struct {
+	Name string `json:"name"`
+}
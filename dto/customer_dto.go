@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"go-admin/models"
+	"time"
+)
+
+type CustomerSummary struct {
+	CustomerID    uint                 `json:"customer_id"`
+	TotalSpent    float64              `json:"total_spent"`
+	VisitCount    int64                `json:"visit_count"`
+	AverageBasket float64              `json:"average_basket"`
+	FirstPurchase *time.Time           `json:"first_purchase"`
+	LastPurchase  *time.Time           `json:"last_purchase"`
+	TopProducts   []CustomerTopProduct `json:"top_products"`
+}
+
+type CustomerTopProduct struct {
+	ProductID uint    `json:"product_id"`
+	Title     string  `json:"title"`
+	Qty       float64 `json:"qty"`
+	Total     float64 `json:"total"`
+}
+
+// CustomerDuplicate adalah pasangan customer yang kemungkinan sama.
+// Reasons berisi "email", "phone" dan/atau "name".
+type CustomerDuplicate struct {
+	Customer     models.Customer `json:"customer"`
+	Duplicate    models.Customer `json:"duplicate"`
+	Reasons      []string        `json:"reasons"`
+	NameDistance int             `json:"name_distance"`
+}
+
+type CustomerMergeRequest struct {
+	DuplicateID uint `json:"duplicate_id"`
+}
+
+type CustomerMergeResult struct {
+	Customer       models.Customer `json:"customer"`
+	MergedID       uint            `json:"merged_id"`
+	Transactions   int64           `json:"transactions"`
+	LoyaltyEntries int64           `json:"loyalty_entries"`
+	Receivables    int64           `json:"receivables"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+type LoyaltyBalance struct {
+	CustomerID   uint       `json:"customer_id"`
+	Points       int64      `json:"points"`
+	PointValue   float64    `json:"point_value"`
+	Value        float64    `json:"value"`
+	ExpiringSoon int64      `json:"expiring_soon"`
+	NextExpiry   *time.Time `json:"next_expiry"`
+}
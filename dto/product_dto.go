@@ -1,26 +1,85 @@
 package dto
 
-import "time"
+import (
+	"go-admin/models"
+	"time"
+)
 
 type ProductRequest struct {
-	Barcode     *string `json:"barcode"`
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	SellPrice   float64 `json:"sell_price"`
-	Price       float64 `json:"price"`
-	Stock       float64 `json:"stock"`
+	Barcode     *string  `json:"barcode"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	SellPrice   float64  `json:"sell_price"`
+	Price       float64  `json:"price"`
+	Stock       float64  `json:"stock"`
+	CategoryID  *uint    `json:"category_id"`
+	Tags        []string `json:"tags"` // nil berarti tag tidak diubah
 }
 
 type ProductResponse struct {
-	ID          uint      `json:"id"`
-	Barcode     string    `json:"barcode"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	SellPrice   float64   `json:"sell_price"`
-	Stock       float64   `json:"stock"`
-	ImgUrl      string    `json:"img_url"`
-	ImageData   []byte    `json:"image_data,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           uint                    `json:"id"`
+	Barcode      string                  `json:"barcode"`
+	Title        string                  `json:"title"`
+	Description  string                  `json:"description"`
+	Price        *float64                `json:"price,omitempty"`
+	SellPrice    float64                 `json:"sell_price"`
+	Stock        float64                 `json:"stock"`
+	ImgUrl       string                  `json:"img_url"`
+	ThumbnailUrl string                  `json:"thumbnail_url"`
+	Images       []models.ProductImage   `json:"images"`
+	CategoryID   *uint                   `json:"category_id"`
+	Category     *models.Category        `json:"category,omitempty"`
+	Tags         []string                `json:"tags"`
+	Options      []string                `json:"options"`
+	Variants     []models.ProductVariant `json:"variants"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+}
+
+// HideCost menghilangkan harga beli dari response.
+func (r *ProductResponse) HideCost() {
+	r.Price = nil
+	for i := range r.Variants {
+		r.Variants[i].HideCost()
+	}
+}
+
+type ProductOptionsRequest struct {
+	Options []string `json:"options"`
+}
+
+type VariantRequest struct {
+	SKU       string            `json:"sku"`
+	Barcode   string            `json:"barcode"`
+	Options   map[string]string `json:"options"`
+	Price     float64           `json:"price"`
+	SellPrice float64           `json:"sell_price"`
+	Stock     float64           `json:"stock"`
+}
+
+type ImageGCOptions struct {
+	GracePeriod time.Duration // object yang lebih baru dari ini tidak dihapus
+	Delete      bool
+}
+
+type OrphanImage struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// MissingImage adalah key yang dipakai di database tapi tidak ada di bucket.
+type MissingImage struct {
+	Entity   string `json:"entity"` // product atau user
+	EntityID uint   `json:"entity_id"`
+	Key      string `json:"key"`
+}
+
+type ImageGCResult struct {
+	Objects    int            `json:"objects"`
+	Referenced int            `json:"referenced"`
+	Orphans    []OrphanImage  `json:"orphans"`
+	Recent     int            `json:"recent"` // orphan yang masih dalam grace period
+	Deleted    int            `json:"deleted"`
+	Missing    []MissingImage `json:"missing"`
 }
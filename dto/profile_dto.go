@@ -0,0 +1,34 @@
+package dto
+
+type UserProfile struct {
+	ID          uint                `json:"id"`
+	FirstName   string              `json:"first_name"`
+	LastName    string              `json:"last_name"`
+	Email       string              `json:"email"`
+	Role        ProfileRole         `json:"role"`
+	Permissions []string            `json:"permissions"`
+	Actions     map[string][]string `json:"actions"`
+	Navigation  []NavItem           `json:"navigation"`
+	Preferences UserPreferences     `json:"preferences"`
+}
+
+type ProfileRole struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	DataScope string `json:"data_scope"`
+}
+
+type NavItem struct {
+	Key        string    `json:"key"`
+	Title      string    `json:"title"`
+	Path       string    `json:"path,omitempty"`
+	Icon       string    `json:"icon,omitempty"`
+	Permission string    `json:"-"`
+	Children   []NavItem `json:"children,omitempty"`
+}
+
+type UserPreferences struct {
+	Language string `json:"language"`
+	Timezone string `json:"timezone"`
+	PageSize int    `json:"page_size"`
+}
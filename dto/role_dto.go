@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RoleDocument adalah format YAML untuk export/import role.
+// Permission ditulis dengan nama, bukan ID, supaya bisa dipakai lintas database.
+type RoleDocument struct {
+	Roles []RoleDefinition `yaml:"roles" json:"roles"`
+}
+
+type RoleDefinition struct {
+	Name        string   `yaml:"name" json:"name"`
+	DataScope   string   `yaml:"data_scope,omitempty" json:"data_scope,omitempty"`
+	Permissions []string `yaml:"permissions" json:"permissions"`
+}
+
+const (
+	RoleActionCreate    = "create"
+	RoleActionUpdate    = "update"
+	RoleActionUnchanged = "unchanged"
+)
+
+type RoleChange struct {
+	Role              string   `json:"role"`
+	Action            string   `json:"action"`
+	DataScopeFrom     string   `json:"data_scope_from,omitempty"`
+	DataScopeTo       string   `json:"data_scope_to,omitempty"`
+	AddPermissions    []string `json:"add_permissions,omitempty"`
+	RemovePermissions []string `json:"remove_permissions,omitempty"`
+}
+
+func (c RoleChange) String() string {
+	switch c.Action {
+	case RoleActionCreate:
+		return fmt.Sprintf("+ %s (data_scope: %s, permissions: %s)", c.Role, c.DataScopeTo, strings.Join(c.AddPermissions, ", "))
+	case RoleActionUnchanged:
+		return fmt.Sprintf("= %s", c.Role)
+	}
+
+	var parts []string
+	if c.DataScopeFrom != c.DataScopeTo {
+		parts = append(parts, fmt.Sprintf("data_scope %s -> %s", c.DataScopeFrom, c.DataScopeTo))
+	}
+	for _, p := range c.AddPermissions {
+		parts = append(parts, "+"+p)
+	}
+	for _, p := range c.RemovePermissions {
+		parts = append(parts, "-"+p)
+	}
+	return fmt.Sprintf("~ %s: %s", c.Role, strings.Join(parts, " "))
+}
+
+func DecodeRoleDocument(data []byte) (*RoleDocument, error) {
+	var doc RoleDocument
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %v", err)
+	}
+	return &doc, nil
+}
+
+func EncodeRoleDocument(doc *RoleDocument) ([]byte, error) {
+	return yaml.Marshal(doc)
+}
@@ -0,0 +1,75 @@
+package dto
+
+import "go-admin/models"
+
+// PayOrderRequest: jika Credit true, kekurangan bayar dicatat sebagai
+// piutang customer dengan jatuh tempo DueDate (default sesuai termin customer).
+type PayOrderRequest struct {
+	CustomerID   uint         `json:"customer_id"`
+	Discount     float64      `json:"discount"`
+	Cash         float64      `json:"cash"`
+	RedeemPoints int64        `json:"redeem_points"`
+	Credit       bool         `json:"credit"`
+	DueDate      *models.Date `json:"due_date"`
+}
+
+type VoidTransactionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// OrderImportOptions: Date dipakai untuk baris yang tidak punya kolom date.
+type OrderImportOptions struct {
+	UserID      uint
+	Date        string
+	UpdateStock bool
+	DryRun      bool
+}
+
+type OrderImportRow struct {
+	Row           int      `json:"row"`
+	OrderID       string   `json:"order_id"`
+	Email         string   `json:"email"`
+	ProductTitle  string   `json:"product_title"`
+	Customer      string   `json:"customer,omitempty"`
+	Product       string   `json:"product,omitempty"`
+	TransactionID uint     `json:"transaction_id,omitempty"`
+	Errors        []string `json:"errors,omitempty"`
+}
+
+type OrderImportResult struct {
+	DryRun           bool             `json:"dry_run"`
+	Orders           int              `json:"orders"`
+	OrdersCreated    int              `json:"orders_created"`
+	OrdersSkipped    int              `json:"orders_skipped"`
+	CustomersCreated int              `json:"customers_created"`
+	CustomersMatched int              `json:"customers_matched"`
+	ProductsCreated  int              `json:"products_created"`
+	ProductsMatched  int              `json:"products_matched"`
+	Created          int              `json:"created"`
+	Failed           int              `json:"failed"`
+	Rows             []OrderImportRow `json:"rows"`
+}
+
+type ReceivablePaymentRequest struct {
+	Amount float64 `json:"amount"`
+	Note   string  `json:"note"`
+}
+
+// ReceivableAging adalah sisa piutang satu customer per hari lewat jatuh
+// tempo. Current adalah piutang yang belum jatuh tempo.
+type ReceivableAging struct {
+	CustomerID uint    `json:"customer_id"`
+	Name       string  `json:"name"`
+	Current    float64 `json:"current"`
+	Days1To30  float64 `json:"days_1_30"`
+	Days31To60 float64 `json:"days_31_60"`
+	Over60     float64 `json:"days_over_60"`
+	Total      float64 `json:"total"`
+	Overdue    float64 `json:"overdue"`
+}
+
+type ReceivableAgingReport struct {
+	Date      models.Date       `json:"date"`
+	Customers []ReceivableAging `json:"customers"`
+	Totals    ReceivableAging   `json:"totals"`
+}
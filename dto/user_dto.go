@@ -0,0 +1,18 @@
+package dto
+
+type UserImportRow struct {
+	Row               int      `json:"row"`
+	Name              string   `json:"name"`
+	Email             string   `json:"email"`
+	Role              string   `json:"role"`
+	Errors            []string `json:"errors,omitempty"`
+	UserID            uint     `json:"user_id,omitempty"`
+	TemporaryPassword string   `json:"temporary_password,omitempty"`
+}
+
+type UserImportResult struct {
+	DryRun  bool            `json:"dry_run"`
+	Created int             `json:"created"`
+	Failed  int             `json:"failed"`
+	Rows    []UserImportRow `json:"rows"`
+}
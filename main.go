@@ -1,9 +1,13 @@
 package main
 
 import (
+	"fmt"
+	"go-admin/command"
 	"go-admin/database"
+	"go-admin/models"
 	"go-admin/routes"
 	"go-admin/service"
+	"os"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -25,7 +29,26 @@ func main() {
 		panic(err)
 	}
 
-	app := fiber.New()
+	// Url publik gambar, misalnya alamat CDN. Default langsung ke bucket MinIO.
+	models.StorageBaseURL = minioService.PublicBaseURL()
+	if publicURL := os.Getenv("STORAGE_PUBLIC_URL"); publicURL != "" {
+		models.StorageBaseURL = publicURL
+	}
+
+	// Jalankan perintah CLI jika ada argumen, misalnya "roles export"
+	if len(os.Args) > 1 {
+		if err := command.Run(db, minioService, os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Batas body cukup untuk beberapa gambar produk sekaligus; ukuran per
+	// gambar dibatasi service.MaxImageSize
+	app := fiber.New(fiber.Config{
+		BodyLimit: 32 << 20,
+	})
 
 	app.Use(cors.New(cors.Config{
 		AllowCredentials: true,
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go-admin/models"
+	"strconv"
+)
+
+// AuditContext menyimpan pelaku, IP dan request ID ke context request
+// supaya perubahan data yang dilakukan service tercatat di audit log.
+// Harus dipasang setelah IsAuthenticated.
+func AuditContext(c *fiber.Ctx) error {
+	requestID := c.Get(fiber.HeaderXRequestID)
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	c.Set(fiber.HeaderXRequestID, requestID)
+
+	issuer, _ := c.Locals("userID").(string)
+	userID, _ := strconv.Atoi(issuer)
+
+	c.SetUserContext(models.WithAuditActor(c.UserContext(), models.AuditActor{
+		UserID:    uint(userID),
+		IP:        c.IP(),
+		RequestID: requestID,
+	}))
+
+	return c.Next()
+}
@@ -2,7 +2,10 @@ package middlewares
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"go-admin/database"
+	"go-admin/models"
 	"go-admin/util"
+	"strconv"
 )
 
 func IsAuthenticated(c *fiber.Ctx) error {
@@ -20,6 +23,15 @@ func IsAuthenticated(c *fiber.Ctx) error {
 		})
 	}
 
+	// User yang sudah dihapus atau dinonaktifkan tidak boleh memakai token lama
+	userId, _ := strconv.Atoi(issuer)
+	var user models.User
+	if err := database.DB.Select("id", "status").First(&user, userId).Error; err != nil || !user.IsActive() {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "unauthenticated",
+		})
+	}
+
 	c.Locals("userID", issuer)
 
 	return c.Next()
@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go-admin/database"
+	"go-admin/models"
+	"go-admin/util"
+	"strconv"
+)
+
+// GetDataScope mengambil scope data dari role user yang sedang login.
+func GetDataScope(c *fiber.Ctx) (models.DataScope, error) {
+	cookie := c.Cookies("jwt")
+
+	Id, err := util.ParseJwt(cookie)
+	if err != nil {
+		return models.DataScope{}, errors.New("unauthenticated")
+	}
+
+	userId, _ := strconv.Atoi(Id)
+
+	var user models.User
+	if err := database.DB.Preload("Role").First(&user, userId).Error; err != nil {
+		return models.DataScope{}, errors.New("unauthenticated")
+	}
+
+	return models.DataScope{
+		UserID: user.Id,
+		Scope:  user.Role.DataScope,
+	}, nil
+}
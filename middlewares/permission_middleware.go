@@ -10,7 +10,9 @@ import (
 	"strconv"
 )
 
-func IsAuthorized(c *fiber.Ctx, page string) error {
+// HasPermission memeriksa apakah role user yang sedang login memiliki
+// permission dengan nama tersebut.
+func HasPermission(c *fiber.Ctx, permission string) error {
 	cookie := c.Cookies("jwt")
 
 	Id, err := util.ParseJwt(cookie)
@@ -20,48 +22,34 @@ func IsAuthorized(c *fiber.Ctx, page string) error {
 
 	userId, _ := strconv.Atoi(Id)
 
-	user := models.User{
-		Id: uint(userId),
+	var user models.User
+	if err := database.DB.Preload("Role.Permissions").First(&user, userId).Error; err != nil {
+		return errors.New("unauthenticated")
 	}
 
-	database.DB.Preload("Role").Find(&user)
-
-	role := models.Role{
-		Id: user.RoleId,
-	}
-
-	database.DB.Preload("Permissions").Find(&role)
-
-	// Debugging: Cetak ID pengguna, peran, dan izin
-	fmt.Printf("User ID: %d\n", user.Id)
-	fmt.Printf("Role ID: %d, Name: %s\n", role.Id, role.Name)
-	fmt.Println("Permissions:")
-	for _, p := range role.Permissions {
-		fmt.Printf("- %s\n", p.Name)
+	for _, p := range user.Role.Permissions {
+		if p.Name == permission {
+			return nil
+		}
 	}
 
-	// Periksa izin berdasarkan metode HTTP dan halaman
-	requiredPermission := ""
-	if c.Method() == "GET" {
-		requiredPermission = "view_" + page
-	} else {
-		requiredPermission = "edit_" + page
-	}
+	return fmt.Errorf("unauthorized: required permission '%s'", permission)
+}
 
-	// Periksa apakah pengguna memiliki izin yang diperlukan
-	hasPermission := false
-	for _, permission := range role.Permissions {
-		if permission.Name == requiredPermission {
-			hasPermission = true
-			break
+// RequirePermission menolak request dengan 403 jika user tidak memiliki
+// permission yang diperlukan route.
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := HasPermission(c, permission); err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": err.Error(),
+			})
 		}
+		return c.Next()
 	}
+}
 
-	if !hasPermission {
-		errorMsg := fmt.Sprintf("unauthorized: required permission '%s'", requiredPermission)
-		c.Status(fiber.StatusUnauthorized)
-		return errors.New(errorMsg)
-	}
-
-	return nil
+// CanViewCost menentukan apakah harga beli dan profit boleh ditampilkan.
+func CanViewCost(c *fiber.Ctx) bool {
+	return HasPermission(c, "view_cost") == nil
 }
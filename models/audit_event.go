@@ -0,0 +1,133 @@
+package models
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Auditable diimplementasikan oleh model yang perubahannya dicatat ke audit_events.
+type Auditable interface {
+	AuditEntity() string
+}
+
+func (Product) AuditEntity() string     { return "product" }
+func (Role) AuditEntity() string        { return "role" }
+func (Customer) AuditEntity() string    { return "customer" }
+func (User) AuditEntity() string        { return "user" }
+func (Transaction) AuditEntity() string { return "transaction" }
+
+const auditSkipKey = "audit:skip"
+
+// SkipAudit menandai query yang tidak perlu dicatat, misalnya kolom turunan
+// yang diisi hook setelah create.
+func SkipAudit(db *gorm.DB) *gorm.DB {
+	return db.Set(auditSkipKey, true)
+}
+
+func AuditSkipped(db *gorm.DB) bool {
+	skip, ok := db.Get(auditSkipKey)
+	return ok && skip == true
+}
+
+type AuditEvent struct {
+	ID         uint      `gorm:"primaryKey;index:idx_audit_created_id,priority:2" json:"id"`
+	ActorID    *uint     `json:"actor_id" gorm:"index"`
+	Actor      *User     `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	Action     string    `json:"action" gorm:"index"`
+	EntityType string    `json:"entity_type" gorm:"index:idx_audit_entity"`
+	EntityID   uint      `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Before     RawJSON   `json:"before" gorm:"type:text"`
+	After      RawJSON   `json:"after" gorm:"type:text"`
+	IP         string    `json:"ip"`
+	RequestID  string    `json:"request_id"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index:idx_audit_created_id,priority:1"`
+}
+
+func (event *AuditEvent) Count(db *gorm.DB) (int64, error) {
+	var total int64
+	err := db.Model(&AuditEvent{}).Count(&total).Error
+	return total, err
+}
+
+func (event *AuditEvent) Take(db *gorm.DB, limit int, offset int) (interface{}, error) {
+	var events []AuditEvent
+	err := db.Preload("Actor", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Offset(offset).Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (event *AuditEvent) ListSchema() ListSchema {
+	return ListSchema{
+		Filters: map[string]string{
+			"actor_id":    "actor_id",
+			"action":      "action",
+			"entity_type": "entity_type",
+			"entity_id":   "entity_id",
+			"request_id":  "request_id",
+			"ip":          "ip",
+			"created_at":  "created_at",
+		},
+		Sorts: map[string]string{
+			"id":         "id",
+			"created_at": "created_at",
+		},
+		DefaultSort: "-created_at,-id",
+		CursorTable: "audit_events",
+	}
+}
+
+// RawJSON disimpan sebagai text di database dan dikirim apa adanya di response.
+type RawJSON []byte
+
+func (j RawJSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j *RawJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = RawJSON(v)
+	default:
+		return errors.New("unsupported type for RawJSON")
+	}
+	return nil
+}
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// AuditActor adalah informasi pelaku perubahan yang dibawa lewat context request.
+type AuditActor struct {
+	UserID    uint
+	IP        string
+	RequestID string
+}
+
+type auditActorKey struct{}
+
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+func AuditActorFrom(ctx context.Context) (AuditActor, bool) {
+	if ctx == nil {
+		return AuditActor{}, false
+	}
+	actor, ok := ctx.Value(auditActorKey{}).(AuditActor)
+	return actor, ok
+}
@@ -6,24 +6,50 @@ import (
 )
 
 type Cart struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	UserID    uint      `json:"user_id"`
-	ProductID uint      `json:"product_id"`
-	Qty       float64   `json:"qty"`
-	Price     float64   `json:"price"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Product   Product   `gorm:"foreignKey:ProductID" json:"product"`
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	UserID    uint            `json:"user_id"`
+	ProductID uint            `json:"product_id"`
+	VariantID *uint           `json:"variant_id"`
+	Qty       float64         `json:"qty"`
+	Price     float64         `json:"price"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Product   Product         `gorm:"foreignKey:ProductID" json:"product"`
+	Variant   *ProductVariant `gorm:"foreignKey:VariantID" json:"variant,omitempty"`
 }
 
-func (cart *Cart) Count(db *gorm.DB) int64 {
+// HideCost menghilangkan harga beli produk dan varian di cart.
+func (cart *Cart) HideCost() {
+	cart.Product.HideCost()
+	if cart.Variant != nil {
+		cart.Variant.HideCost()
+	}
+}
+
+func (cart *Cart) Count(db *gorm.DB) (int64, error) {
 	var total int64
-	db.Model(&Cart{}).Count(&total)
-	return total
+	err := db.Model(&Cart{}).Count(&total).Error
+	return total, err
 }
 
-func (cart *Cart) Take(db *gorm.DB, limit int, offset int) interface{} {
+func (cart *Cart) Take(db *gorm.DB, limit int, offset int) (interface{}, error) {
 	var carts []Cart
-	db.Preload("Product").Offset(offset).Limit(limit).Find(&carts)
-	return carts
+	err := db.Preload("Product").Preload("Variant").Offset(offset).Limit(limit).Find(&carts).Error
+	return carts, err
+}
+
+func (cart *Cart) ListSchema() ListSchema {
+	columns := map[string]string{
+		"id":         "id",
+		"user_id":    "user_id",
+		"product_id": "product_id",
+		"variant_id": "variant_id",
+		"qty":        "qty",
+		"created_at": "created_at",
+	}
+	return ListSchema{
+		Filters:     columns,
+		Sorts:       columns,
+		DefaultSort: "-created_at",
+	}
 }
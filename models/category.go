@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Category adalah kategori produk yang bisa bertingkat lewat ParentID.
+type Category struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Name      string     `json:"name"`
+	ParentID  *uint      `json:"parent_id" gorm:"index"`
+	Children  []Category `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (Category) AuditEntity() string { return "category" }
+
+// Tag adalah label bebas pada produk, misalnya "promo" atau "halal".
+type Tag struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Name         string    `json:"name" gorm:"uniqueIndex"`
+	ProductCount int64     `gorm:"-" json:"product_count,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (Tag) AuditEntity() string { return "tag" }
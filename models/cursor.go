@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Cursor menandai posisi baris pada keyset pagination (created_at, id).
+// Client menerimanya sebagai string opaque lewat next_cursor/prev_cursor.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"id"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// paginateCursor mengambil satu halaman berurutan created_at DESC, id DESC
+// tanpa OFFSET, sehingga tetap cepat di halaman yang jauh. Total tidak
+// dihitung karena COUNT pada tabel besar sama mahalnya.
+func paginateCursor(db *gorm.DB, entity Entity, query ListQuery, schema ListSchema) (fiber.Map, error) {
+	if len(query.Sort) > 0 {
+		return nil, errors.New("sort is not supported with cursor pagination")
+	}
+
+	table := schema.CursorTable
+	limit := query.Limit(schema)
+
+	var cursor *Cursor
+	if query.Cursor != "" {
+		c, err := DecodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor = c
+	}
+
+	backward := cursor != nil && cursor.Backward
+	order := table + ".created_at DESC, " + table + ".id DESC"
+	if backward {
+		order = table + ".created_at ASC, " + table + ".id ASC"
+	}
+
+	if cursor != nil {
+		op := "<"
+		if backward {
+			op = ">"
+		}
+		db = db.Where("("+table+".created_at "+op+" ?) OR ("+table+".created_at = ? AND "+table+".id "+op+" ?)",
+			cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	// Ambil satu baris lebih untuk mengetahui apakah masih ada halaman berikutnya
+	data, err := entity.Take(db.Order(order), limit+1, 0)
+	if err != nil {
+		return nil, err
+	}
+	rows := reflect.ValueOf(data)
+	hasMore := rows.Len() > limit
+	if hasMore {
+		rows = rows.Slice(0, limit)
+	}
+	if backward {
+		swap := reflect.Swapper(rows.Interface())
+		for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	var nextCursor, prevCursor string
+	if rows.Len() > 0 {
+		first := cursorOf(rows.Index(0))
+		last := cursorOf(rows.Index(rows.Len() - 1))
+
+		if hasMore || backward {
+			nextCursor = last.Encode()
+		}
+		if (cursor != nil && !backward) || (backward && hasMore) {
+			first.Backward = true
+			prevCursor = first.Encode()
+		}
+	}
+
+	return fiber.Map{
+		"data": rows.Interface(),
+		"meta": fiber.Map{
+			"per_page":    limit,
+			"next_cursor": nextCursor,
+			"prev_cursor": prevCursor,
+		},
+	}, nil
+}
+
+func cursorOf(row reflect.Value) Cursor {
+	row = reflect.Indirect(row)
+	id := row.FieldByName("ID")
+	if !id.IsValid() {
+		id = row.FieldByName("Id")
+	}
+	return Cursor{
+		CreatedAt: row.FieldByName("CreatedAt").Interface().(time.Time),
+		ID:        uint(id.Uint()),
+	}
+}
@@ -1,7 +1,52 @@
 package models
 
+import "gorm.io/gorm"
+
 type Customer struct {
-	Id    uint   `json:"id"`
-	Email string `json:"email" gorm:"unique"`
-	Name  string `json:"name"`
+	Id               uint    `json:"id"`
+	Email            *string `json:"email" gorm:"unique"`
+	Name             string  `json:"name" gorm:"index"`
+	Phone            string  `json:"phone" gorm:"index"`
+	Address          string  `json:"address"`
+	Birthday         *Date   `json:"birthday" gorm:"type:date"`
+	TaxID            string  `json:"tax_id"`
+	Notes            string  `json:"notes" gorm:"type:text"`
+	MemberCardNumber *string `json:"member_card_number" gorm:"uniqueIndex"`
+	CreditLimit      float64 `json:"credit_limit"` // 0 berarti tidak boleh belanja kredit
+	PaymentTermDays  int     `json:"payment_term_days"`
+
+	// Diisi oleh lookup customer, tidak disimpan
+	Outstanding   float64 `gorm:"-" json:"outstanding,omitempty"`
+	OverdueAmount float64 `gorm:"-" json:"overdue_amount,omitempty"`
+	Overdue       bool    `gorm:"-" json:"overdue,omitempty"`
+}
+
+func (customer *Customer) Count(db *gorm.DB) (int64, error) {
+	var total int64
+	err := db.Model(&Customer{}).Count(&total).Error
+	return total, err
+}
+
+func (customer *Customer) Take(db *gorm.DB, limit int, offset int) (interface{}, error) {
+	var customers []Customer
+	err := db.Offset(offset).Limit(limit).Find(&customers).Error
+	return customers, err
+}
+
+func (customer *Customer) ListSchema() ListSchema {
+	columns := map[string]string{
+		"id":                 "id",
+		"name":               "name",
+		"email":              "email",
+		"phone":              "phone",
+		"member_card_number": "member_card_number",
+		"credit_limit":       "credit_limit",
+	}
+	return ListSchema{
+		Filters:        columns,
+		Sorts:          columns,
+		Search:         []string{"name", "email", "phone", "member_card_number"},
+		DefaultSort:    "id",
+		DefaultPerPage: 5,
+	}
 }
@@ -0,0 +1,43 @@
+package models
+
+import "gorm.io/gorm"
+
+const (
+	DataScopeAll = "all"
+	DataScopeOwn = "own"
+)
+
+// DataScope menentukan data transaksi mana yang boleh dilihat user,
+// berdasarkan scope pada role-nya.
+type DataScope struct {
+	UserID uint
+	Scope  string
+}
+
+func IsValidDataScope(scope string) bool {
+	return scope == DataScopeAll || scope == DataScopeOwn
+}
+
+func (s DataScope) OwnOnly() bool {
+	return s.Scope != DataScopeAll
+}
+
+// Transactions membatasi query ke transaksi yang dibuat oleh user sendiri.
+// Kolom bisa diberi prefix tabel, misalnya "transactions.user_id".
+func (s DataScope) Transactions(column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if !s.OwnOnly() {
+			return db
+		}
+		return db.Where(column+" = ?", s.UserID)
+	}
+}
+
+// Profits membatasi query profit ke transaksi milik user sendiri.
+func (s DataScope) Profits(db *gorm.DB) *gorm.DB {
+	if !s.OwnOnly() {
+		return db
+	}
+	return db.Where("transaction_id IN (?)",
+		db.Session(&gorm.Session{NewDB: true}).Model(&Transaction{}).Select("id").Where("user_id = ?", s.UserID))
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+)
+
+const DateLayout = "2006-01-02"
+
+// Date adalah tanggal tanpa jam, dikirim sebagai "2006-01-02" di JSON.
+type Date struct {
+	time.Time
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + d.Format(DateLayout) + `"`), nil
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		d.Time = time.Time{}
+		return nil
+	}
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return errors.New("invalid date, expected YYYY-MM-DD: " + value)
+	}
+	d.Time = t
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.Format(DateLayout), nil
+}
+
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		d.Time = time.Time{}
+	case time.Time:
+		d.Time = v
+	case string:
+		return d.parse(v)
+	case []byte:
+		return d.parse(string(v))
+	default:
+		return errors.New("unsupported type for Date")
+	}
+	return nil
+}
+
+func (d *Date) parse(value string) error {
+	if len(value) > len(DateLayout) {
+		value = value[:len(DateLayout)]
+	}
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return err
+	}
+	d.Time = t
+	return nil
+}
+
+// Today mengembalikan tanggal hari ini (waktu lokal).
+func Today() Date {
+	now := time.Now()
+	return Date{time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)}
+}
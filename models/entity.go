@@ -3,6 +3,7 @@ package models
 import "gorm.io/gorm"
 
 type Entity interface {
-	Count(db *gorm.DB) int64
-	Take(db *gorm.DB, limit int, offset int) interface{}
-}
\ No newline at end of file
+	Count(db *gorm.DB) (int64, error)
+	Take(db *gorm.DB, limit int, offset int) (interface{}, error)
+	ListSchema() ListSchema
+}
@@ -0,0 +1,239 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ListSchema adalah whitelist field yang boleh dipakai client untuk
+// filter dan sort pada sebuah entity. Key adalah nama field di query
+// string, value adalah kolom di database.
+type ListSchema struct {
+	Filters        map[string]string
+	Sorts          map[string]string
+	Search         []string
+	DefaultSort    string
+	DefaultPerPage int
+	// CursorTable diisi untuk entity yang mendukung keyset pagination
+	// (kolom created_at dan id di tabel tersebut).
+	CursorTable string
+}
+
+type ListFilter struct {
+	Field string
+	Op    string
+	Value string
+}
+
+type ListSort struct {
+	Field string
+	Desc  bool
+}
+
+// ListQuery adalah hasil parsing query string daftar data:
+//
+//	?filter[status][eq]=active&filter[created_at][gte]=2024-01-01
+//	?sort=-created_at,id&page=2&per_page=50&search=budi
+//	?cursor=&per_page=50
+//
+// filter[field]=value sama dengan filter[field][eq]=value. Adanya parameter
+// cursor (boleh kosong untuk halaman pertama) mengaktifkan keyset pagination.
+type ListQuery struct {
+	Page      int
+	PerPage   int
+	Search    string
+	Filters   []ListFilter
+	Sort      []ListSort
+	UseCursor bool
+	Cursor    string
+}
+
+var listFilterKey = regexp.MustCompile(`^filter\[([a-z0-9_]+)\](?:\[([a-z]+)\])?$`)
+
+var listOperators = map[string]string{
+	"eq":   "=",
+	"ne":   "<>",
+	"gt":   ">",
+	"gte":  ">=",
+	"lt":   "<",
+	"lte":  "<=",
+	"like": "LIKE",
+	"in":   "IN",
+	"null": "IS NULL",
+}
+
+func ParseListQuery(values url.Values) (ListQuery, error) {
+	var query ListQuery
+
+	if page := values.Get("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return query, errors.New("invalid page: " + page)
+		}
+		query.Page = n
+	}
+	if perPage := values.Get("per_page"); perPage != "" {
+		n, err := strconv.Atoi(perPage)
+		if err != nil || n < 1 {
+			return query, errors.New("invalid per_page: " + perPage)
+		}
+		query.PerPage = n
+	}
+	query.Search = strings.TrimSpace(values.Get("search"))
+
+	if values.Has("cursor") {
+		query.UseCursor = true
+		query.Cursor = values.Get("cursor")
+	}
+
+	if s := values.Get("sort"); s != "" {
+		sorts, err := parseListSort(s)
+		if err != nil {
+			return query, err
+		}
+		query.Sort = sorts
+	}
+
+	// Urutkan key supaya hasil parsing selalu sama
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.HasPrefix(key, "filter") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		match := listFilterKey.FindStringSubmatch(key)
+		if match == nil {
+			return query, errors.New("invalid filter: " + key)
+		}
+		op := match[2]
+		if op == "" {
+			op = "eq"
+		}
+		if _, ok := listOperators[op]; !ok {
+			return query, fmt.Errorf("invalid filter operator for %s: %s", match[1], op)
+		}
+		for _, value := range values[key] {
+			query.Filters = append(query.Filters, ListFilter{Field: match[1], Op: op, Value: value})
+		}
+	}
+
+	return query, nil
+}
+
+func parseListSort(value string) ([]ListSort, error) {
+	var sorts []ListSort
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		desc := strings.HasPrefix(part, "-")
+		field := strings.TrimPrefix(part, "-")
+		if field == "" {
+			return nil, errors.New("invalid sort: " + value)
+		}
+		sorts = append(sorts, ListSort{Field: field, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// Limit mengembalikan jumlah baris per halaman, dibatasi MaxPageSize.
+func (q ListQuery) Limit(schema ListSchema) int {
+	limit := q.PerPage
+	if limit < 1 {
+		limit = schema.DefaultPerPage
+	}
+	if limit < 1 {
+		limit = 15
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	return limit
+}
+
+// Filter menerapkan filter dan search yang ada di whitelist schema.
+func (q ListQuery) Filter(db *gorm.DB, schema ListSchema) (*gorm.DB, error) {
+	for _, f := range q.Filters {
+		column, ok := schema.Filters[f.Field]
+		if !ok {
+			return nil, errors.New("invalid filter field: " + f.Field)
+		}
+
+		switch f.Op {
+		case "like":
+			db = db.Where("UPPER("+column+") LIKE ?", "%"+strings.ToUpper(f.Value)+"%")
+		case "in":
+			db = db.Where(column+" IN ?", strings.Split(f.Value, ","))
+		case "null":
+			isNull, err := strconv.ParseBool(f.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s null filter: %s", f.Field, f.Value)
+			}
+			if isNull {
+				db = db.Where(column + " IS NULL")
+			} else {
+				db = db.Where(column + " IS NOT NULL")
+			}
+		default:
+			db = db.Where(column+" "+listOperators[f.Op]+" ?", f.Value)
+		}
+	}
+
+	if q.Search != "" && len(schema.Search) > 0 {
+		search := "%" + strings.ToUpper(q.Search) + "%"
+		conditions := make([]string, len(schema.Search))
+		args := make([]interface{}, len(schema.Search))
+		for i, column := range schema.Search {
+			conditions[i] = "UPPER(" + column + ") LIKE ?"
+			args[i] = search
+		}
+		db = db.Where(strings.Join(conditions, " OR "), args...)
+	}
+
+	return db, nil
+}
+
+// OrderBy menyusun klausa ORDER BY dari sort yang ada di whitelist schema.
+func (q ListQuery) OrderBy(schema ListSchema) (string, error) {
+	sorts := q.Sort
+	if len(sorts) == 0 && schema.DefaultSort != "" {
+		sorts, _ = parseListSort(schema.DefaultSort)
+	}
+
+	parts := make([]string, 0, len(sorts))
+	for _, s := range sorts {
+		column, ok := schema.Sorts[s.Field]
+		if !ok {
+			return "", errors.New("invalid sort field: " + s.Field)
+		}
+		if s.Desc {
+			column += " DESC"
+		}
+		parts = append(parts, column)
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// Without mengembalikan salinan query tanpa filter pada field tersebut,
+// untuk filter yang ditangani sendiri oleh service.
+func (q ListQuery) Without(field string) (ListQuery, []ListFilter) {
+	var removed []ListFilter
+	filters := make([]ListFilter, 0, len(q.Filters))
+	for _, f := range q.Filters {
+		if f.Field == field {
+			removed = append(removed, f)
+			continue
+		}
+		filters = append(filters, f)
+	}
+	q.Filters = filters
+	return q, removed
+}
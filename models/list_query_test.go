@@ -0,0 +1,182 @@
+package models
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+func TestParseListQueryFilters(t *testing.T) {
+	values, _ := url.ParseQuery("filter[status]=active&filter[created_at][gte]=2024-01-01" +
+		"&filter[tag]=a&filter[tag]=b&filter[deleted_at][null]=true")
+
+	query, err := ParseListQuery(values)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	want := []ListFilter{
+		{Field: "created_at", Op: "gte", Value: "2024-01-01"},
+		{Field: "deleted_at", Op: "null", Value: "true"},
+		{Field: "status", Op: "eq", Value: "active"},
+		{Field: "tag", Op: "eq", Value: "a"},
+		{Field: "tag", Op: "eq", Value: "b"},
+	}
+	if !reflect.DeepEqual(query.Filters, want) {
+		t.Errorf("filters = %+v, want %+v", query.Filters, want)
+	}
+}
+
+func TestParseListQueryRejectsInvalidInput(t *testing.T) {
+	cases := []string{
+		"filter[status][regex]=a",
+		"filter[Status]=a",
+		"filter[status]]=a",
+		"filter=a",
+		"page=0",
+		"page=abc",
+		"per_page=-1",
+		"sort=name,,id",
+		"sort=-",
+	}
+	for _, raw := range cases {
+		values, _ := url.ParseQuery(raw)
+		if _, err := ParseListQuery(values); err == nil {
+			t.Errorf("%s: expected error", raw)
+		}
+	}
+}
+
+func TestParseListQuerySortAndPaging(t *testing.T) {
+	values, _ := url.ParseQuery("sort=-created_at, id&page=2&per_page=50&search= budi &cursor=")
+
+	query, err := ParseListQuery(values)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	wantSort := []ListSort{{Field: "created_at", Desc: true}, {Field: "id"}}
+	if !reflect.DeepEqual(query.Sort, wantSort) {
+		t.Errorf("sort = %+v, want %+v", query.Sort, wantSort)
+	}
+	if query.Page != 2 || query.PerPage != 50 || query.Search != "budi" {
+		t.Errorf("page=%d per_page=%d search=%q", query.Page, query.PerPage, query.Search)
+	}
+	if !query.UseCursor || query.Cursor != "" {
+		t.Errorf("empty cursor parameter should enable cursor pagination")
+	}
+}
+
+func TestListQueryWhitelist(t *testing.T) {
+	schema := ListSchema{
+		Filters:     map[string]string{"name": "customers.name"},
+		Sorts:       map[string]string{"name": "customers.name", "id": "customers.id"},
+		DefaultSort: "-id",
+	}
+	db := openTestDB(t).Session(&gorm.Session{DryRun: true})
+
+	if _, err := (ListQuery{Filters: []ListFilter{{Field: "password", Op: "eq", Value: "x"}}}).Filter(db, schema); err == nil {
+		t.Error("filter outside schema should be rejected")
+	}
+	if _, err := (ListQuery{Sort: []ListSort{{Field: "password"}}}).OrderBy(schema); err == nil {
+		t.Error("sort outside schema should be rejected")
+	}
+
+	order, err := ListQuery{}.OrderBy(schema)
+	if err != nil || order != "customers.id DESC" {
+		t.Errorf("default order = %q, %v", order, err)
+	}
+	order, err = ListQuery{Sort: []ListSort{{Field: "name"}, {Field: "id", Desc: true}}}.OrderBy(schema)
+	if err != nil || order != "customers.name, customers.id DESC" {
+		t.Errorf("order = %q, %v", order, err)
+	}
+
+	filtered, err := ListQuery{Filters: []ListFilter{{Field: "name", Op: "like", Value: "bud"}}}.Filter(db, schema)
+	if err != nil {
+		t.Fatalf("filter: %v", err)
+	}
+	stmt := filtered.Find(&[]Customer{}).Statement
+	if sql := stmt.SQL.String(); !strings.Contains(sql, "UPPER(customers.name) LIKE ?") {
+		t.Errorf("sql = %s", sql)
+	}
+	if !reflect.DeepEqual(stmt.Vars, []interface{}{"%BUD%"}) {
+		t.Errorf("vars = %v", stmt.Vars)
+	}
+}
+
+func TestListQueryLimit(t *testing.T) {
+	schema := ListSchema{DefaultPerPage: 5}
+	cases := []struct {
+		perPage, want int
+	}{
+		{0, 5},
+		{20, 20},
+		{MaxPageSize + 1, MaxPageSize},
+	}
+	for _, tc := range cases {
+		if got := (ListQuery{PerPage: tc.perPage}).Limit(schema); got != tc.want {
+			t.Errorf("per_page %d: limit = %d, want %d", tc.perPage, got, tc.want)
+		}
+	}
+	if got := (ListQuery{}).Limit(ListSchema{}); got != 15 {
+		t.Errorf("limit without default = %d, want 15", got)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), ID: 42, Backward: true}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID || !decoded.Backward {
+		t.Errorf("decoded = %+v, want %+v", decoded, cursor)
+	}
+
+	for _, value := range []string{"not base64!", "bm90IGpzb24", Cursor{CreatedAt: cursor.CreatedAt}.Encode()} {
+		if _, err := DecodeCursor(value); err == nil {
+			t.Errorf("%q: expected invalid cursor", value)
+		}
+	}
+}
+
+type brokenCustomer struct {
+	Customer
+}
+
+func (brokenCustomer) ListSchema() ListSchema {
+	return ListSchema{Filters: map[string]string{"missing": "missing_column"}}
+}
+
+func TestPaginateReturnsQueryErrors(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&Customer{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	query := ListQuery{Filters: []ListFilter{{Field: "missing", Op: "eq", Value: "x"}}}
+	if _, err := Paginate(db, &brokenCustomer{}, query); err == nil {
+		t.Error("expected database error to be returned")
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoyaltyRule menentukan poin yang didapat customer saat transaksi.
+// Aturan umum (ProductID kosong) memberi Points poin untuk setiap
+// kelipatan SpendUnit belanja. Aturan produk memberi Points poin per qty
+// dan menggantikan aturan umum untuk produk tersebut.
+type LoyaltyRule struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID *uint     `json:"product_id" gorm:"uniqueIndex"`
+	Product   *Product  `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	SpendUnit float64   `json:"spend_unit"`
+	Points    float64   `json:"points"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (LoyaltyRule) AuditEntity() string { return "loyalty_rule" }
+
+const (
+	LoyaltyEarn    = "earn"
+	LoyaltyRedeem  = "redeem"
+	LoyaltyExpire  = "expire"
+	LoyaltyReverse = "reverse"
+)
+
+// LoyaltyEntry adalah satu baris ledger poin customer. Saldo adalah jumlah
+// Points semua entry. Remaining pada entry yang menambah poin adalah sisa
+// poin yang belum dipakai atau kedaluwarsa, dipakai FIFO saat redeem.
+// Remaining negatif adalah utang poin dari void transaksi yang poinnya sudah
+// terpakai, dilunasi oleh poin yang didapat berikutnya. SourceEntryID pada
+// entry expire dan reverse menunjuk entry poin yang dikurangi.
+type LoyaltyEntry struct {
+	ID            uint       `gorm:"primaryKey;index:idx_loyalty_created_id,priority:2" json:"id"`
+	CustomerID    uint       `json:"customer_id" gorm:"index"`
+	TransactionID *uint      `json:"transaction_id" gorm:"index"`
+	SourceEntryID *uint      `json:"source_entry_id" gorm:"index"`
+	Type          string     `json:"type"`
+	Points        int64      `json:"points"`
+	Remaining     int64      `json:"remaining"`
+	ExpiresAt     *time.Time `json:"expires_at" gorm:"index"`
+	Note          string     `json:"note"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index:idx_loyalty_created_id,priority:1"`
+}
+
+func (entry *LoyaltyEntry) Count(db *gorm.DB) (int64, error) {
+	var total int64
+	err := db.Model(&LoyaltyEntry{}).Count(&total).Error
+	return total, err
+}
+
+func (entry *LoyaltyEntry) Take(db *gorm.DB, limit int, offset int) (interface{}, error) {
+	var entries []LoyaltyEntry
+	err := db.Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+func (entry *LoyaltyEntry) ListSchema() ListSchema {
+	return ListSchema{
+		Filters: map[string]string{
+			"type":           "type",
+			"transaction_id": "transaction_id",
+			"created_at":     "created_at",
+		},
+		Sorts: map[string]string{
+			"id":         "id",
+			"created_at": "created_at",
+		},
+		DefaultSort: "-created_at,-id",
+		CursorTable: "loyalty_entries",
+	}
+}
@@ -1,24 +1,68 @@
 package models
 
 import (
+	"errors"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 	"math"
 )
 
-func Paginate(db *gorm.DB, entity Entity, page int) fiber.Map {
-	limit := 15
+// MaxPageSize adalah batas jumlah baris per halaman yang boleh diminta client.
+const MaxPageSize = 100
+
+// Paginate menerapkan filter, sort dan paging dari query ke entity. Filter
+// dan sort di luar ListSchema entity ditolak. Jika query memakai cursor,
+// halaman diambil dengan keyset pagination (lihat paginateCursor). Error
+// query, misalnya nilai filter yang tidak cocok dengan tipe kolom, dikembalikan
+// ke controller dan dijawab 400 alih-alih menghasilkan list kosong.
+func Paginate(db *gorm.DB, entity Entity, query ListQuery) (fiber.Map, error) {
+	schema := entity.ListSchema()
+
+	filtered, err := query.Filter(db, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.UseCursor {
+		if schema.CursorTable == "" {
+			return nil, errors.New("cursor pagination is not supported for this list")
+		}
+		return paginateCursor(filtered, entity, query, schema)
+	}
+	order, err := query.OrderBy(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	// Session supaya Count dan Take tidak saling mengubah statement
+	filtered = filtered.Session(&gorm.Session{})
+
+	page := query.Page
+	if page < 1 {
+		page = 1
+	}
+	limit := query.Limit(schema)
 	offset := (page - 1) * limit
 
-	data := entity.Take(db, limit, offset)
-	total := entity.Count(db)
+	total, err := entity.Count(filtered)
+	if err != nil {
+		return nil, err
+	}
+	if order != "" {
+		filtered = filtered.Order(order)
+	}
+	data, err := entity.Take(filtered, limit, offset)
+	if err != nil {
+		return nil, err
+	}
 
 	return fiber.Map{
 		"data": data,
 		"meta": fiber.Map{
 			"total":     total,
 			"page":      page,
-			"last_page": math.Ceil(float64(int(total) / limit)),
+			"per_page":  limit,
+			"last_page": math.Ceil(float64(total) / float64(limit)),
 		},
-	}
-}
\ No newline at end of file
+	}, nil
+}
@@ -1,22 +1,88 @@
 package models
 
 import (
+	"encoding/json"
+	"gorm.io/gorm"
 	"time"
 )
 
 type Product struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Barcode     string    `json:"barcode"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Stock       float64   `json:"stock"`
-	Price       float64   `json:"price"`
-	ImgUrl      string    `json:"img_url"`
-	SellPrice   float64   `json:"sell_price"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	Barcode     string           `json:"barcode"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Stock       float64          `json:"stock"`
+	Price       float64          `json:"price"`
+	ImageKey    string           `json:"-"` // key object gambar utama, lihat ImageUrl
+	SellPrice   float64          `json:"sell_price"`
+	CategoryID  *uint            `json:"category_id" gorm:"index"`
+	Category    *Category        `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Tags        []Tag            `json:"tags,omitempty" gorm:"many2many:product_tags"`
+	Options     []string         `json:"options,omitempty" gorm:"serializer:json"` // sumbu varian, misalnya ["size", "color"]
+	Variants    []ProductVariant `json:"variants,omitempty" gorm:"foreignKey:ProductID"`
+	Images      []ProductImage   `json:"images,omitempty" gorm:"foreignKey:ProductID"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+
+	costHidden bool
 }
 
 func (Product) TableName() string {
 	return "products"
-}
\ No newline at end of file
+}
+
+func (p *Product) Count(db *gorm.DB) (int64, error) {
+	var total int64
+	err := db.Model(&Product{}).Count(&total).Error
+	return total, err
+}
+
+func (p *Product) Take(db *gorm.DB, limit int, offset int) (interface{}, error) {
+	var products []Product
+	err := db.Preload("Category").Preload("Tags").Preload("Variants").Preload("Images", OrderedImages).
+		Offset(offset).Limit(limit).Find(&products).Error
+	return products, err
+}
+
+// ListSchema sengaja tidak memuat kolom price (harga beli), supaya user
+// tanpa view_cost tidak bisa menebak harga beli lewat filter.
+func (p *Product) ListSchema() ListSchema {
+	columns := map[string]string{
+		"id":          "id",
+		"barcode":     "barcode",
+		"title":       "title",
+		"stock":       "stock",
+		"sell_price":  "sell_price",
+		"category_id": "category_id",
+		"created_at":  "created_at",
+	}
+	return ListSchema{
+		Filters:        columns,
+		Sorts:          columns,
+		Search:         []string{"title", "barcode"},
+		DefaultSort:    "id",
+		DefaultPerPage: 5,
+	}
+}
+
+// HideCost menghilangkan harga beli dari response JSON untuk user
+// yang tidak memiliki permission view_cost.
+func (p *Product) HideCost() {
+	p.costHidden = true
+	for i := range p.Variants {
+		p.Variants[i].HideCost()
+	}
+}
+
+func (p Product) MarshalJSON() ([]byte, error) {
+	type product Product
+	out := struct {
+		product
+		ImgUrl string   `json:"img_url"`
+		Price  *float64 `json:"price,omitempty"`
+	}{product: product(p), ImgUrl: p.ImageUrl("original")}
+	if !p.costHidden {
+		out.Price = &p.Price
+	}
+	return json.Marshal(out)
+}
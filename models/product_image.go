@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductImage adalah satu gambar di galeri produk. OriginalKey adalah file
+// asli, MediumKey dan ThumbnailKey adalah versi kecil yang dibuat saat
+// upload. Bucket bersifat private, jadi di JSON ketiganya dikirim sebagai url
+// proxy GET /api/products/:id/image, lihat ProductImageURL.
+type ProductImage struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ProductID    uint      `json:"product_id" gorm:"index"`
+	Position     int       `json:"position"`
+	IsPrimary    bool      `json:"primary"`
+	OriginalKey  string    `json:"-"`
+	MediumKey    string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (ProductImage) AuditEntity() string { return "product_image" }
+
+// Keys mengembalikan semua key object milik gambar ini.
+func (img ProductImage) Keys() []string {
+	return []string{img.OriginalKey, img.MediumKey, img.ThumbnailKey}
+}
+
+func (img ProductImage) MarshalJSON() ([]byte, error) {
+	type productImage ProductImage
+	return json.Marshal(struct {
+		productImage
+		Url          string `json:"url"`
+		MediumUrl    string `json:"medium_url"`
+		ThumbnailUrl string `json:"thumbnail_url"`
+	}{
+		productImage: productImage(img),
+		Url:          ProductImageURL(img.ProductID, img.ID, "original"),
+		MediumUrl:    ProductImageURL(img.ProductID, img.ID, "medium"),
+		ThumbnailUrl: ProductImageURL(img.ProductID, img.ID, "thumbnail"),
+	})
+}
+
+// ProductImageURL membuat url proxy gambar produk dengan ukuran size
+// (thumbnail, medium, original). imageID 0 berarti gambar utama.
+func ProductImageURL(productID, imageID uint, size string) string {
+	url := fmt.Sprintf("/api/products/%d/image?size=%s", productID, size)
+	if imageID != 0 {
+		url += fmt.Sprintf("&image_id=%d", imageID)
+	}
+	return url
+}
+
+// OrderedImages dipakai saat preload Images supaya urutan galeri terjaga.
+func OrderedImages(db *gorm.DB) *gorm.DB {
+	return db.Order("position, id")
+}
+
+// ImageUrl mengembalikan url proxy gambar utama produk dengan ukuran size,
+// atau string kosong jika produk tidak punya gambar. Produk lama yang belum
+// punya galeri dilayani proxy dari gambar aslinya.
+func (p *Product) ImageUrl(size string) string {
+	for _, img := range p.Images {
+		if img.IsPrimary {
+			return ProductImageURL(p.ID, img.ID, size)
+		}
+	}
+	if p.ImageKey == "" {
+		return ""
+	}
+	return ProductImageURL(p.ID, 0, size)
+}
+
+// ThumbnailUrl mengembalikan url thumbnail gambar utama produk.
+func (p *Product) ThumbnailUrl() string {
+	return p.ImageUrl("thumbnail")
+}
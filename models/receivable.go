@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Receivable adalah piutang dari satu transaksi kredit. Sisa piutang adalah
+// Amount - Paid; SettledAt diisi saat piutang lunas.
+type Receivable struct {
+	ID            uint                `gorm:"primaryKey" json:"id"`
+	CustomerID    uint                `json:"customer_id" gorm:"index"`
+	Customer      *Customer           `json:"customer,omitempty" gorm:"foreignKey:CustomerID"`
+	TransactionID uint                `json:"transaction_id" gorm:"uniqueIndex"`
+	Transaction   *Transaction        `json:"transaction,omitempty" gorm:"foreignKey:TransactionID"`
+	Amount        float64             `json:"amount"`
+	Paid          float64             `json:"paid"`
+	Balance       float64             `gorm:"-" json:"balance"`
+	DueDate       Date                `json:"due_date" gorm:"type:date;index"`
+	Overdue       bool                `gorm:"-" json:"overdue"`
+	SettledAt     *time.Time          `json:"settled_at" gorm:"index"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	Payments      []ReceivablePayment `json:"payments,omitempty" gorm:"foreignKey:ReceivableID"`
+}
+
+func (Receivable) AuditEntity() string { return "receivable" }
+
+func (receivable *Receivable) AfterFind(tx *gorm.DB) error {
+	receivable.Balance = receivable.Amount - receivable.Paid
+	receivable.Overdue = receivable.SettledAt == nil && receivable.DueDate.Before(Today().Time)
+	return nil
+}
+
+func (receivable *Receivable) Count(db *gorm.DB) (int64, error) {
+	var total int64
+	err := db.Model(&Receivable{}).Count(&total).Error
+	return total, err
+}
+
+func (receivable *Receivable) Take(db *gorm.DB, limit int, offset int) (interface{}, error) {
+	var receivables []Receivable
+	err := db.Preload("Customer").Offset(offset).Limit(limit).Find(&receivables).Error
+	return receivables, err
+}
+
+func (receivable *Receivable) ListSchema() ListSchema {
+	columns := map[string]string{
+		"id":             "id",
+		"customer_id":    "customer_id",
+		"transaction_id": "transaction_id",
+		"amount":         "amount",
+		"paid":           "paid",
+		"due_date":       "due_date",
+		"settled_at":     "settled_at",
+		"created_at":     "created_at",
+	}
+	return ListSchema{
+		Filters:     columns,
+		Sorts:       columns,
+		DefaultSort: "due_date,id",
+	}
+}
+
+// ReceivablePayment adalah satu pembayaran (boleh sebagian) atas piutang.
+type ReceivablePayment struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ReceivableID uint      `json:"receivable_id" gorm:"index"`
+	UserID       uint      `json:"user_id"`
+	Amount       float64   `json:"amount"`
+	Note         string    `json:"note"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (ReceivablePayment) AuditEntity() string { return "receivable_payment" }
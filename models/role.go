@@ -3,5 +3,6 @@ package models
 type Role struct {
 	Id          uint         `json:"id"`
 	Name        string       `json:"name"`
+	DataScope   string       `json:"data_scope" gorm:"default:all"`
 	Permissions []Permission `json:"permissions,omitempty" gorm:"many2many:role_permissions;"`
 }
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// StorageBaseURL adalah url publik tempat object MinIO bisa diakses, misalnya
+// "http://localhost:9000/products" atau alamat CDN. Database hanya menyimpan
+// key object; url lengkap dibuat saat response dikirim.
+var StorageBaseURL = "http://localhost:9000/products"
+
+// ObjectURL membuat url publik dari key object.
+func ObjectURL(key string) string {
+	if key == "" || isAbsoluteURL(key) {
+		return key
+	}
+	return strings.TrimRight(StorageBaseURL, "/") + "/" + strings.TrimLeft(key, "/")
+}
+
+// ObjectKey mengambil key object dari url lama yang masih berupa url lengkap.
+// Nilai yang sudah berupa key dikembalikan apa adanya.
+func ObjectKey(value string) string {
+	if !isAbsoluteURL(value) {
+		return value
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return value
+	}
+	return path.Base(u.Path)
+}
+
+func isAbsoluteURL(value string) bool {
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
@@ -7,35 +7,106 @@ import (
 )
 
 type Transaction struct {
-	ID                 uint                `gorm:"primaryKey" json:"id"`
-	UserID             uint                `json:"user_id"`
+	ID                 uint                `gorm:"primaryKey;index:idx_transactions_created_id,priority:2" json:"id"`
+	UserID             uint                `json:"user_id" gorm:"index"`
 	User               *User               `json:"user" gorm:"foreignKey:UserID"`
 	CustomerID         uint                `json:"customer_id"`
 	Customer           *Customer           `json:"customer" gorm:"foreignKey:CustomerID"`
 	Invoice            string              `json:"invoice"`
+	ExternalID         string              `json:"external_id,omitempty" gorm:"index"`
 	Cash               float64             `json:"cash"`
 	Change             float64             `json:"change"`
+	CreditAmount       float64             `json:"credit_amount"`
 	Discount           float64             `json:"discount"`
 	DiscountPercent    float64             `gorm:"-" json:"discount_percent"`
 	GrandTotal         float64             `json:"grand_total"`
-	CreatedAt          time.Time           `json:"created_at"`
+	PointsEarned       int64               `json:"points_earned"`
+	PointsRedeemed     int64               `json:"points_redeemed"`
+	PointsDiscount     float64             `json:"points_discount"`
+	VoidedAt           *time.Time          `json:"voided_at"`
+	VoidedBy           *uint               `json:"voided_by"`
+	VoidReason         string              `json:"void_reason"`
+	CreatedAt          time.Time           `json:"created_at" gorm:"index:idx_transactions_created_id,priority:1"`
 	UpdatedAt          time.Time           `json:"updated_at"`
 	TransactionDetails []TransactionDetail `gorm:"foreignKey:TransactionID" json:"transaction_details"`
 }
 
+// AfterCreate mengisi nomor invoice dari ID. Audit create dicatat setelah
+// hook ini sehingga invoice sudah ikut di event create, dan update ini
+// sendiri tidak perlu dicatat.
 func (transaction *Transaction) AfterCreate(tx *gorm.DB) (err error) {
 	year2Digit := transaction.CreatedAt.Year() % 100
-	invoice := fmt.Sprintf("%d.%d.INV/ORD/%d", year2Digit, transaction.CreatedAt.Month(), transaction.ID)
-	return tx.Model(transaction).Update("invoice", invoice).Error
+	transaction.Invoice = fmt.Sprintf("%d.%d.INV/ORD/%d", year2Digit, transaction.CreatedAt.Month(), transaction.ID)
+	return SkipAudit(tx).Model(transaction).UpdateColumn("invoice", transaction.Invoice).Error
+}
+
+func (transaction *Transaction) Count(db *gorm.DB) (int64, error) {
+	var total int64
+	err := db.Model(&Transaction{}).Count(&total).Error
+	return total, err
+}
+
+// Take tidak memuat detail transaksi; detail diambil per transaksi.
+func (transaction *Transaction) Take(db *gorm.DB, limit int, offset int) (interface{}, error) {
+	var transactions []Transaction
+	err := db.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Preload("Customer").Offset(offset).Limit(limit).Find(&transactions).Error
+	return transactions, err
+}
+
+func (transaction *Transaction) ListSchema() ListSchema {
+	columns := map[string]string{
+		"id":          "id",
+		"user_id":     "user_id",
+		"customer_id": "customer_id",
+		"invoice":     "invoice",
+		"discount":    "discount",
+		"grand_total": "grand_total",
+		"created_at":  "created_at",
+	}
+	filters := map[string]string{"voided_at": "voided_at", "external_id": "external_id"}
+	for field, column := range columns {
+		filters[field] = column
+	}
+	return ListSchema{
+		Filters:     filters,
+		Sorts:       columns,
+		Search:      []string{"invoice"},
+		DefaultSort: "-created_at",
+		CursorTable: "transactions",
+	}
+}
+
+// NotVoided mengecualikan transaksi yang sudah di-void dari laporan.
+// Kolom bisa diberi prefix tabel, misalnya "transactions.voided_at".
+func NotVoided(column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(column + " IS NULL")
+	}
+}
+
+// HideCost menghilangkan harga beli produk pada detail transaksi.
+func (transaction *Transaction) HideCost() {
+	for i := range transaction.TransactionDetails {
+		if transaction.TransactionDetails[i].Product != nil {
+			transaction.TransactionDetails[i].Product.HideCost()
+		}
+		if transaction.TransactionDetails[i].Variant != nil {
+			transaction.TransactionDetails[i].Variant.HideCost()
+		}
+	}
 }
 
 type TransactionDetail struct {
-	ID            uint     `gorm:"primaryKey" json:"id"`
-	TransactionID uint     `json:"transaction_id"`
-	ProductID     uint     `json:"product_id"`
-	Product       *Product `json:"product" gorm:"foreignKey:ProductID"`
-	Qty           float64  `json:"qty"`
-	Price         float64  `json:"price"`
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	TransactionID uint            `json:"transaction_id" gorm:"index"`
+	ProductID     uint            `json:"product_id"`
+	Product       *Product        `json:"product" gorm:"foreignKey:ProductID"`
+	VariantID     *uint           `json:"variant_id"`
+	Variant       *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID"`
+	Qty           float64         `json:"qty"`
+	Price         float64         `json:"price"`
 }
 
 func (TransactionDetail) TableName() string {
@@ -1,18 +1,45 @@
 package models
 
 import (
+	"encoding/json"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	UserStatusActive   = "active"
+	UserStatusInactive = "inactive"
 )
 
 type User struct {
-	Id        uint   `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Email     string `json:"email" gorm:"unique"`
-	Password  []byte `json:"-"`
-	RoleId    uint   `json:"role_id"`
-	Role      Role   `json:"role" gorm:"foreignKey:RoleId"`
+	Id        uint           `json:"id"`
+	FirstName string         `json:"first_name"`
+	LastName  string         `json:"last_name"`
+	Email     string         `json:"email" gorm:"unique"`
+	Password  []byte         `json:"-"`
+	RoleId    uint           `json:"role_id"`
+	Role      Role           `json:"role" gorm:"foreignKey:RoleId"`
+	AvatarKey string         `json:"-"` // key object avatar, lihat ObjectURL
+	Language  string         `json:"language" gorm:"default:id"`
+	Timezone  string         `json:"timezone" gorm:"default:Asia/Jakarta"`
+	PageSize  int            `json:"page_size" gorm:"default:15"`
+	Status    string         `json:"status" gorm:"default:active;index"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+func (user User) MarshalJSON() ([]byte, error) {
+	type userJSON User
+	return json.Marshal(struct {
+		userJSON
+		AvatarUrl string `json:"avatar_url"`
+	}{userJSON: userJSON(user), AvatarUrl: ObjectURL(user.AvatarKey)})
+}
+
+func (user *User) IsActive() bool {
+	return user.Status != UserStatusInactive && !user.DeletedAt.Valid
 }
 
 func (user *User) SetPassword(password string) {
@@ -24,17 +51,35 @@ func (user *User) ComparePassword(password string) error {
 	return bcrypt.CompareHashAndPassword(user.Password, []byte(password))
 }
 
-func (user *User) Count(db *gorm.DB) int64 {
+func (user *User) Count(db *gorm.DB) (int64, error) {
 	var total int64
-	db.Model(&User{}).Count(&total)
+	err := db.Model(&User{}).Count(&total).Error
 
-	return total
+	return total, err
 }
 
-func (user *User) Take(db *gorm.DB, limit int, offset int) interface{} {
+func (user *User) Take(db *gorm.DB, limit int, offset int) (interface{}, error) {
 	var users []User
 
-	db.Preload("Role").Offset(offset).Limit(limit).Find(&users)
+	err := db.Preload("Role").Offset(offset).Limit(limit).Find(&users).Error
+
+	return users, err
+}
 
-	return users
+func (user *User) ListSchema() ListSchema {
+	columns := map[string]string{
+		"id":         "id",
+		"first_name": "first_name",
+		"last_name":  "last_name",
+		"email":      "email",
+		"role_id":    "role_id",
+		"status":     "status",
+		"created_at": "created_at",
+	}
+	return ListSchema{
+		Filters:     columns,
+		Sorts:       columns,
+		Search:      []string{"first_name", "last_name", "email"},
+		DefaultSort: "id",
+	}
 }
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ProductVariant adalah satu kombinasi opsi produk (misalnya ukuran M warna
+// merah) dengan barcode, harga dan stok sendiri. Key pada Options harus sama
+// dengan Product.Options.
+type ProductVariant struct {
+	ID        uint              `gorm:"primaryKey" json:"id"`
+	ProductID uint              `json:"product_id" gorm:"index"`
+	SKU       string            `json:"sku" gorm:"index"`
+	Barcode   string            `json:"barcode" gorm:"index"`
+	Options   map[string]string `json:"options" gorm:"serializer:json"`
+	Price     float64           `json:"price"`
+	SellPrice float64           `json:"sell_price"`
+	Stock     float64           `json:"stock"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+
+	costHidden bool
+}
+
+func (ProductVariant) AuditEntity() string { return "product_variant" }
+
+// HideCost menghilangkan harga beli varian dari response JSON.
+func (v *ProductVariant) HideCost() {
+	v.costHidden = true
+}
+
+func (v ProductVariant) MarshalJSON() ([]byte, error) {
+	type variant ProductVariant
+	if !v.costHidden {
+		return json.Marshal(variant(v))
+	}
+	return json.Marshal(struct {
+		variant
+		Price *float64 `json:"price,omitempty"`
+	}{variant: variant(v)})
+}
@@ -9,11 +9,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// Setup mendaftarkan semua route. Setiap route harus masuk salah satu
+// kelompok: public, self (cukup login, hanya untuk akun sendiri), atau
+// dilindungi permission lewat middlewares.RequirePermission.
 func Setup(app *fiber.App, db *gorm.DB, minioService *service.MinioService) {
 	dashboardService := service.NewDashboardService(db)
 	dashboardController := controller.NewDashboardController(dashboardService)
 
-	userService := service.NewUserService(db)
+	userService := service.NewUserService(db, minioService)
 	userController := controller.NewUserController(userService)
 
 	roleService := service.NewRoleService(db)
@@ -34,236 +37,141 @@ func Setup(app *fiber.App, db *gorm.DB, minioService *service.MinioService) {
 	profitService := service.NewProfitService(db)
 	profitController := controller.NewProfitController(profitService)
 
-	app.Get("/api/", dashboardController.GetDashboard)
+	loyaltyService := service.NewLoyaltyService(db)
+	loyaltyController := controller.NewLoyaltyController(loyaltyService)
 
+	receivableService := service.NewReceivableService(db)
+	receivableController := controller.NewReceivableController(receivableService)
+
+	categoryService := service.NewCategoryService(db)
+	categoryController := controller.NewCategoryController(categoryService)
+
+	auditService := service.NewAuditService(db)
+	auditController := controller.NewAuditController(auditService)
+
+	can := middlewares.RequirePermission
+
+	//public
 	app.Post("/api/register", controller.Register)
 	app.Post("/api/login", controller.Login)
 
-	app.Use(middlewares.IsAuthenticated)
+	app.Use(middlewares.IsAuthenticated, middlewares.AuditContext)
 
+	//self
 	app.Put("/api/users/info", controller.UpdateInfo)
 	app.Put("/api/users/password", controller.UpdatePassword)
+	app.Put("/api/users/preferences", controller.UpdatePreferences)
+	app.Post("/api/users/avatar", userController.UploadMyAvatar)
+	app.Delete("/api/users/avatar", userController.DeleteMyAvatar)
 
 	app.Get("/api/user", controller.User)
 	app.Post("/api/logout", controller.Logout)
 
-	//app.Get("/api/users", userController.AllUsers)
-	//app.Post("/api/users", userController.CreateUser)
-	//app.Get("/api/users/:id", userController.GetUser)
-	//app.Put("/api/users/:id", userController.UpdateUser)
-	//app.Delete("/api/users/:id", userController.DeleteUser)
+	//dashboard
+	app.Get("/api/", can("view_dashboard"), dashboardController.GetDashboard)
 
 	//users
-	app.Get("/api/users", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "users"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return userController.AllUsers(c)
-	})
-
-	app.Post("/api/users", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "users"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return userController.CreateUser(c)
-	})
-
-	app.Get("/api/users/:id", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "users"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return userController.GetUser(c)
-	})
-
-	app.Put("/api/users/:id", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "users"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return userController.UpdateUser(c)
-	})
-
-	app.Delete("/api/users/:id", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "users"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return userController.DeleteUser(c)
-	})
-
-	//app.Get("/api/roles", roleController.AllRoles)
-	//app.Post("/api/roles", roleController.CreateRole)
-	//app.Get("/api/roles/:id", roleController.GetRole)
-	//app.Put("/api/roles/:id", roleController.UpdateRole)
-	//app.Delete("/api/roles/:id", roleController.DeleteRole)
+	app.Get("/api/users", can("view_users"), userController.AllUsers)
+	app.Post("/api/users", can("edit_users"), userController.CreateUser)
+	app.Post("/api/users/import", can("edit_users"), userController.ImportUsers)
+	app.Get("/api/users/:id", can("view_users"), userController.GetUser)
+	app.Put("/api/users/:id", can("edit_users"), userController.UpdateUser)
+	app.Delete("/api/users/:id", can("edit_users"), userController.DeleteUser)
+	app.Post("/api/users/:id/activate", can("edit_users"), userController.ActivateUser)
+	app.Post("/api/users/:id/deactivate", can("edit_users"), userController.DeactivateUser)
+	app.Post("/api/users/:id/restore", can("edit_users"), userController.RestoreUser)
+	app.Post("/api/users/:id/avatar", can("edit_users"), userController.UploadAvatar)
+	app.Delete("/api/users/:id/avatar", can("edit_users"), userController.DeleteAvatar)
 
 	//roles
-	app.Get("/api/roles", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "roles"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return roleController.AllRoles(c)
-	})
-
-	app.Post("/api/roles", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "roles"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return roleController.CreateRole(c)
-	})
-
-	app.Get("/api/roles/:id", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "roles"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return roleController.GetRole(c)
-	})
-
-	app.Put("/api/roles/:id", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "roles"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return roleController.UpdateRole(c)
-	})
-
-	app.Delete("/api/roles/:id", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "roles"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return roleController.DeleteRole(c)
-	})
-
-	app.Get("/api/dropdown/customers", customerController.DropdownCustomers)
-
-	app.Get("/api/customers", customerController.AllCustomers)
-	app.Post("/api/customers", customerController.CreateCustomer)
-	app.Get("/api/customers/:id", customerController.GetCustomer)
-	app.Put("/api/customers/:id", customerController.UpdateCustomer)
-	app.Delete("/api/customers/:id", customerController.DeleteCustomer)
+	app.Get("/api/roles", can("view_roles"), roleController.AllRoles)
+	app.Post("/api/roles", can("edit_roles"), roleController.CreateRole)
+	app.Get("/api/roles/export", can("view_roles"), roleController.ExportRoles)
+	app.Post("/api/roles/import", can("edit_roles"), roleController.ImportRoles)
+	app.Get("/api/roles/:id", can("view_roles"), roleController.GetRole)
+	app.Put("/api/roles/:id", can("edit_roles"), roleController.UpdateRole)
+	app.Delete("/api/roles/:id", can("edit_roles"), roleController.DeleteRole)
+
+	app.Get("/api/permissions", can("view_roles"), controller.AllPermissions)
+
+	//customers
+	app.Get("/api/dropdown/customers", can("view_transactions"), customerController.DropdownCustomers)
+	app.Get("/api/customers/lookup", can("view_transactions"), customerController.LookupCustomers)
+	app.Get("/api/customers/duplicates", can("view_customers"), customerController.FindDuplicates)
+
+	app.Get("/api/customers", can("view_customers"), customerController.AllCustomers)
+	app.Post("/api/customers", can("edit_customers"), customerController.CreateCustomer)
+	app.Get("/api/customers/:id", can("view_customers"), customerController.GetCustomer)
+	app.Put("/api/customers/:id", can("edit_customers"), customerController.UpdateCustomer)
+	app.Delete("/api/customers/:id", can("edit_customers"), customerController.DeleteCustomer)
+	app.Post("/api/customers/:id/merge", can("edit_customers"), customerController.MergeCustomer)
+	app.Get("/api/customers/:id/transactions", can("view_customers"), customerController.CustomerTransactions)
+	app.Get("/api/customers/:id/summary", can("view_customers"), customerController.CustomerSummary)
+	app.Get("/api/customers/:id/points", can("view_transactions"), loyaltyController.CustomerPoints)
+	app.Get("/api/customers/:id/points/history", can("view_customers"), loyaltyController.CustomerPointsHistory)
+
+	//receivables
+	app.Get("/api/receivables", can("view_receivables"), receivableController.AllReceivables)
+	app.Get("/api/receivables/aging", can("view_receivables"), receivableController.Aging)
+	app.Get("/api/receivables/:id", can("view_receivables"), receivableController.GetReceivable)
+	app.Post("/api/receivables/:id/payments", can("edit_receivables"), receivableController.RecordPayment)
+
+	//loyalty
+	app.Get("/api/loyalty/rules", can("view_customers"), loyaltyController.AllRules)
+	app.Post("/api/loyalty/rules", can("edit_loyalty"), loyaltyController.CreateRule)
+	app.Put("/api/loyalty/rules/:id", can("edit_loyalty"), loyaltyController.UpdateRule)
+	app.Delete("/api/loyalty/rules/:id", can("edit_loyalty"), loyaltyController.DeleteRule)
 
 	//products
-	app.Post("/api/products", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "products"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return productController.Create(c)
-	})
-
-	app.Put("/api/products/:id", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "products"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return productController.Update(c)
-	})
-
-	app.Delete("/api/products/:id", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "products"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return productController.Delete(c)
-	})
-
-	app.Get("/api/products/:id", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "products"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return productController.GetByID(c)
-	})
-
-	app.Post("/api/products/search", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "products"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return productController.GetAll(c)
-	})
-
-	app.Get("/api/permissions", controller.AllPermissions)
-
-	//app.Get("/api/transactions/searchProduct", transactionController.SearchProduct)
-	//app.Post("/api/transactions/addToCart", transactionController.AddToCart)
-	//app.Delete("/api/transactions/destroyCart", transactionController.DestroyCart)
-	//app.Get("/api/transactions/getCart", transactionController.GetCart)
-	//app.Post("/api/transactions/payOrder", transactionController.PayOrder)
+	app.Post("/api/products", can("edit_products"), productController.Create)
+	app.Put("/api/products/:id", can("edit_products"), productController.Update)
+	app.Delete("/api/products/:id", can("edit_products"), productController.Delete)
+	app.Get("/api/products/:id", can("view_products"), productController.GetByID)
+	app.Post("/api/products/search", can("view_products"), productController.GetAll)
+	app.Get("/api/products/:id/variants", can("view_products"), productController.GetVariants)
+	app.Put("/api/products/:id/options", can("edit_products"), productController.SetOptions)
+	app.Post("/api/products/:id/variants", can("edit_products"), productController.CreateVariant)
+	app.Put("/api/products/:id/variants/:variantId", can("edit_products"), productController.UpdateVariant)
+	app.Delete("/api/products/:id/variants/:variantId", can("edit_products"), productController.DeleteVariant)
+	app.Get("/api/products/:id/image", can("view_products"), productController.Image)
+	app.Get("/api/products/:id/images", can("view_products"), productController.GetImages)
+	app.Post("/api/products/:id/images", can("edit_products"), productController.AddImages)
+	app.Put("/api/products/:id/images/order", can("edit_products"), productController.ReorderImages)
+	app.Post("/api/products/:id/images/:imageId/primary", can("edit_products"), productController.SetPrimaryImage)
+	app.Delete("/api/products/:id/images/:imageId", can("edit_products"), productController.DeleteImage)
+
+	//categories & tags
+	app.Get("/api/categories", can("view_products"), categoryController.AllCategories)
+	app.Post("/api/categories", can("edit_products"), categoryController.CreateCategory)
+	app.Put("/api/categories/:id", can("edit_products"), categoryController.UpdateCategory)
+	app.Delete("/api/categories/:id", can("edit_products"), categoryController.DeleteCategory)
+	app.Get("/api/tags", can("view_products"), categoryController.AllTags)
+	app.Post("/api/tags", can("edit_products"), categoryController.CreateTag)
+	app.Put("/api/tags/:id", can("edit_products"), categoryController.UpdateTag)
+	app.Delete("/api/tags/:id", can("edit_products"), categoryController.DeleteTag)
 
 	//transaction
-	app.Get("/api/transactions/searchProduct", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "transactions"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return transactionController.SearchProduct(c)
-	})
-
-	app.Post("/api/transactions/addToCart", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "transactions"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return transactionController.AddToCart(c)
-	})
-
-	app.Delete("/api/transactions/destroyCart", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "transactions"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return transactionController.DestroyCart(c)
-	})
-
-	app.Get("/api/transactions/getCart", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "transactions"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return transactionController.GetCart(c)
-	})
-
-	app.Post("/api/transactions/payOrder", func(c *fiber.Ctx) error {
-		if err := middlewares.IsAuthorized(c, "transactions"); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-		return transactionController.PayOrder(c)
-	})
-
-	app.Post("/api/sales/filter", salesController.FilterSales)
-	app.Post("/api/sales/export-excel", salesController.ExportExcel)
-	app.Post("/api/sales/export-pdf", salesController.ExportPDF)
-
-	app.Post("/api/profit/filter", profitController.FilterProfit)
-	app.Post("/api/profit/export-excel", profitController.ExportExcel)
-	app.Post("/api/profit/export-pdf", profitController.ExportPDF)
+	app.Get("/api/transactions/searchProduct", can("view_transactions"), transactionController.SearchProduct)
+	app.Post("/api/transactions/addToCart", can("edit_transactions"), transactionController.AddToCart)
+	app.Delete("/api/transactions/destroyCart", can("edit_transactions"), transactionController.DestroyCart)
+	app.Get("/api/transactions/getCart", can("view_transactions"), transactionController.GetCart)
+	app.Post("/api/transactions/payOrder", can("edit_transactions"), transactionController.PayOrder)
+
+	app.Get("/api/carts", can("view_transactions"), transactionController.ListCarts)
+	app.Get("/api/transactions", can("view_sales"), transactionController.ListTransactions)
+	app.Post("/api/transactions/import", can("import_transactions"), transactionController.ImportOrders)
+	app.Post("/api/transactions/:id/void", can("void_transactions"), transactionController.VoidTransaction)
+
+	//reports
+	app.Post("/api/sales/filter", can("view_sales"), salesController.FilterSales)
+	app.Post("/api/sales/export-excel", can("view_sales"), salesController.ExportExcel)
+	app.Post("/api/sales/export-pdf", can("view_sales"), salesController.ExportPDF)
+
+	app.Post("/api/profit/filter", can("view_profit"), profitController.FilterProfit)
+	app.Post("/api/profit/export-excel", can("view_profit"), profitController.ExportExcel)
+	app.Post("/api/profit/export-pdf", can("view_profit"), profitController.ExportPDF)
+
+	//audit
+	app.Get("/api/audit", can("view_audit"), auditController.AllEvents)
+	app.Get("/api/audit/export", can("view_audit"), auditController.ExportExcel)
 }
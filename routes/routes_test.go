@@ -0,0 +1,555 @@
+package routes
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go-admin/database"
+	"go-admin/models"
+	"go-admin/service"
+	"go-admin/util"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	public = "public"
+	self   = "self"
+)
+
+var routeParam = regexp.MustCompile(`:[^/]+`)
+
+type routeCase struct {
+	method     string
+	path       string
+	permission string
+}
+
+// routeCases harus mencakup semua route yang didaftarkan Setup.
+var routeCases = []routeCase{
+	{"POST", "/api/register", public},
+	{"POST", "/api/login", public},
+
+	{"PUT", "/api/users/info", self},
+	{"PUT", "/api/users/password", self},
+	{"PUT", "/api/users/preferences", self},
+	{"POST", "/api/users/avatar", self},
+	{"DELETE", "/api/users/avatar", self},
+	{"GET", "/api/user", self},
+	{"POST", "/api/logout", self},
+
+	{"GET", "/api/", "view_dashboard"},
+
+	{"GET", "/api/users", "view_users"},
+	{"POST", "/api/users", "edit_users"},
+	{"POST", "/api/users/import", "edit_users"},
+	{"GET", "/api/users/:id", "view_users"},
+	{"PUT", "/api/users/:id", "edit_users"},
+	{"DELETE", "/api/users/:id", "edit_users"},
+	{"POST", "/api/users/:id/activate", "edit_users"},
+	{"POST", "/api/users/:id/deactivate", "edit_users"},
+	{"POST", "/api/users/:id/restore", "edit_users"},
+	{"POST", "/api/users/:id/avatar", "edit_users"},
+	{"DELETE", "/api/users/:id/avatar", "edit_users"},
+
+	{"GET", "/api/roles", "view_roles"},
+	{"POST", "/api/roles", "edit_roles"},
+	{"GET", "/api/roles/export", "view_roles"},
+	{"POST", "/api/roles/import", "edit_roles"},
+	{"GET", "/api/roles/:id", "view_roles"},
+	{"PUT", "/api/roles/:id", "edit_roles"},
+	{"DELETE", "/api/roles/:id", "edit_roles"},
+	{"GET", "/api/permissions", "view_roles"},
+
+	{"GET", "/api/dropdown/customers", "view_transactions"},
+	{"GET", "/api/customers/lookup", "view_transactions"},
+	{"GET", "/api/customers/duplicates", "view_customers"},
+	{"GET", "/api/customers", "view_customers"},
+	{"POST", "/api/customers", "edit_customers"},
+	{"GET", "/api/customers/:id", "view_customers"},
+	{"PUT", "/api/customers/:id", "edit_customers"},
+	{"DELETE", "/api/customers/:id", "edit_customers"},
+	{"POST", "/api/customers/:id/merge", "edit_customers"},
+	{"GET", "/api/customers/:id/transactions", "view_customers"},
+	{"GET", "/api/customers/:id/summary", "view_customers"},
+	{"GET", "/api/customers/:id/points", "view_transactions"},
+	{"GET", "/api/customers/:id/points/history", "view_customers"},
+
+	{"GET", "/api/receivables", "view_receivables"},
+	{"GET", "/api/receivables/aging", "view_receivables"},
+	{"GET", "/api/receivables/:id", "view_receivables"},
+	{"POST", "/api/receivables/:id/payments", "edit_receivables"},
+
+	{"GET", "/api/loyalty/rules", "view_customers"},
+	{"POST", "/api/loyalty/rules", "edit_loyalty"},
+	{"PUT", "/api/loyalty/rules/:id", "edit_loyalty"},
+	{"DELETE", "/api/loyalty/rules/:id", "edit_loyalty"},
+
+	{"POST", "/api/products", "edit_products"},
+	{"PUT", "/api/products/:id", "edit_products"},
+	{"DELETE", "/api/products/:id", "edit_products"},
+	{"GET", "/api/products/:id", "view_products"},
+	{"POST", "/api/products/search", "view_products"},
+	{"GET", "/api/products/:id/variants", "view_products"},
+	{"PUT", "/api/products/:id/options", "edit_products"},
+	{"POST", "/api/products/:id/variants", "edit_products"},
+	{"PUT", "/api/products/:id/variants/:variantId", "edit_products"},
+	{"DELETE", "/api/products/:id/variants/:variantId", "edit_products"},
+	{"GET", "/api/products/:id/image", "view_products"},
+	{"GET", "/api/products/:id/images", "view_products"},
+	{"POST", "/api/products/:id/images", "edit_products"},
+	{"PUT", "/api/products/:id/images/order", "edit_products"},
+	{"POST", "/api/products/:id/images/:imageId/primary", "edit_products"},
+	{"DELETE", "/api/products/:id/images/:imageId", "edit_products"},
+
+	{"GET", "/api/categories", "view_products"},
+	{"POST", "/api/categories", "edit_products"},
+	{"PUT", "/api/categories/:id", "edit_products"},
+	{"DELETE", "/api/categories/:id", "edit_products"},
+	{"GET", "/api/tags", "view_products"},
+	{"POST", "/api/tags", "edit_products"},
+	{"PUT", "/api/tags/:id", "edit_products"},
+	{"DELETE", "/api/tags/:id", "edit_products"},
+
+	{"GET", "/api/transactions/searchProduct", "view_transactions"},
+	{"POST", "/api/transactions/addToCart", "edit_transactions"},
+	{"DELETE", "/api/transactions/destroyCart", "edit_transactions"},
+	{"GET", "/api/transactions/getCart", "view_transactions"},
+	{"POST", "/api/transactions/payOrder", "edit_transactions"},
+	{"GET", "/api/carts", "view_transactions"},
+	{"GET", "/api/transactions", "view_sales"},
+	{"POST", "/api/transactions/import", "import_transactions"},
+	{"POST", "/api/transactions/:id/void", "void_transactions"},
+
+	{"POST", "/api/sales/filter", "view_sales"},
+	{"POST", "/api/sales/export-excel", "view_sales"},
+	{"POST", "/api/sales/export-pdf", "view_sales"},
+
+	{"POST", "/api/profit/filter", "view_profit"},
+	{"POST", "/api/profit/export-excel", "view_profit"},
+	{"POST", "/api/profit/export-pdf", "view_profit"},
+
+	{"GET", "/api/audit", "view_audit"},
+	{"GET", "/api/audit/export", "view_audit"},
+}
+
+type testRole struct {
+	name        string
+	permissions []string
+}
+
+var testRoles = []testRole{
+	{"admin", database.PermissionNames},
+	{"cashier", []string{"view_dashboard", "view_transactions", "edit_transactions", "view_sales"}},
+	{"nobody", nil},
+}
+
+func setupTestApp(t *testing.T) (*fiber.App, map[string]string) {
+	t.Helper()
+
+	db := openTestDB(t)
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	tokens := make(map[string]string)
+	for i, r := range testRoles {
+		var permissions []models.Permission
+		if len(r.permissions) > 0 {
+			db.Where("name IN ?", r.permissions).Find(&permissions)
+		}
+		tokens[r.name] = createTestUser(t, db, r.name, i, permissions)
+	}
+
+	return newTestApp(t, db), tokens
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	database.DB = db
+	if err := database.RegisterAuditCallbacks(db); err != nil {
+		t.Fatalf("register audit callbacks: %v", err)
+	}
+	return db
+}
+
+// createTestUser membuat role dengan permissions dan satu user di role itu,
+// lalu mengembalikan token JWT user tersebut.
+func createTestUser(t *testing.T, db *gorm.DB, name string, i int, permissions []models.Permission) string {
+	t.Helper()
+
+	role := models.Role{Name: name, Permissions: permissions}
+	if err := db.Create(&role).Error; err != nil {
+		t.Fatalf("create role %s: %v", name, err)
+	}
+
+	user := models.User{
+		FirstName: name,
+		Email:     name + strconv.Itoa(i) + "@example.com",
+		RoleId:    role.Id,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user %s: %v", name, err)
+	}
+
+	token, err := util.GenerateJwt(strconv.Itoa(int(user.Id)))
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	return token
+}
+
+func newTestApp(t *testing.T, db *gorm.DB) *fiber.App {
+	t.Helper()
+
+	minioService, err := service.NewMinioService("localhost:9000", "test", "test", "products", false)
+	if err != nil {
+		t.Fatalf("create minio service: %v", err)
+	}
+
+	app := fiber.New()
+	Setup(app, db, minioService)
+	return app
+}
+
+func TestRoutesAreCovered(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	known := make(map[string]bool)
+	for _, rc := range routeCases {
+		known[rc.method+" "+rc.path] = true
+	}
+
+	for _, r := range app.GetRoutes(true) {
+		if r.Method == "HEAD" || r.Method == "USE" {
+			continue
+		}
+		if !known[r.Method+" "+r.Path] {
+			t.Errorf("route %s %s has no entry in routeCases", r.Method, r.Path)
+		}
+	}
+}
+
+func TestPermissionMatrix(t *testing.T) {
+	app, tokens := setupTestApp(t)
+
+	for _, rc := range routeCases {
+		for _, role := range append([]testRole{{name: "anonymous"}}, testRoles...) {
+			rc, role := rc, role
+			t.Run(rc.method+" "+rc.path+" as "+role.name, func(t *testing.T) {
+				req := httptest.NewRequest(rc.method, requestPath(rc.path), nil)
+				if token, ok := tokens[role.name]; ok {
+					req.AddCookie(&http.Cookie{Name: "jwt", Value: token})
+				}
+
+				resp, err := app.Test(req, -1)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+
+				switch want := expectedStatus(rc, role); want {
+				case http.StatusOK:
+					if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+						t.Errorf("expected access, got %d", resp.StatusCode)
+					}
+				default:
+					if resp.StatusCode != want {
+						t.Errorf("expected %d, got %d", want, resp.StatusCode)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestUpgradedRolesKeepAccess mensimulasikan database dari versi lama yang
+// hanya punya permission view_/edit_ untuk users, roles, products dan
+// transactions. Setelah migrasi, role lama harus tetap bisa membuka fitur
+// yang dulu terbuka untuk semua user yang login.
+func TestUpgradedRolesKeepAccess(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{}, &models.AuditEvent{}); err != nil {
+		t.Fatalf("migrate legacy tables: %v", err)
+	}
+
+	legacy := make(map[string]models.Permission)
+	for _, page := range []string{"users", "roles", "products", "transactions"} {
+		for _, action := range []string{"view_", "edit_"} {
+			permission := models.Permission{Name: action + page}
+			if err := db.Create(&permission).Error; err != nil {
+				t.Fatalf("create permission: %v", err)
+			}
+			legacy[permission.Name] = permission
+		}
+	}
+
+	var all []models.Permission
+	for _, permission := range legacy {
+		all = append(all, permission)
+	}
+	adminToken := createTestUser(t, db, "legacy_admin", 0, all)
+	cashierToken := createTestUser(t, db, "legacy_cashier", 1, []models.Permission{
+		legacy["view_transactions"], legacy["edit_transactions"],
+	})
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	app := newTestApp(t, db)
+
+	cases := []struct {
+		token, method, path string
+		allowed             bool
+	}{
+		{adminToken, "GET", "/api/", true},
+		{adminToken, "POST", "/api/sales/filter", true},
+		{adminToken, "POST", "/api/profit/filter", true},
+		{adminToken, "GET", "/api/audit", true},
+		{adminToken, "GET", "/api/receivables", true},
+		{adminToken, "POST", "/api/transactions/:id/void", true},
+		{cashierToken, "GET", "/api/", true},
+		{cashierToken, "POST", "/api/sales/filter", true},
+		{cashierToken, "GET", "/api/customers", true},
+		{cashierToken, "POST", "/api/profit/filter", false},
+		{cashierToken, "GET", "/api/audit", false},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, requestPath(tc.path), nil)
+		req.AddCookie(&http.Cookie{Name: "jwt", Value: tc.token})
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		denied := resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden
+		if denied == tc.allowed {
+			t.Errorf("%s %s: allowed=%v, got %d", tc.method, tc.path, tc.allowed, resp.StatusCode)
+		}
+	}
+
+	// Permission yang dicabut admin tidak boleh diberikan ulang oleh migrasi
+	var viewSales models.Permission
+	db.Where("name = ?", "view_sales").First(&viewSales)
+	db.Exec("DELETE FROM role_permissions WHERE permission_id = ?", viewSales.Id)
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate test database again: %v", err)
+	}
+	var granted int64
+	db.Table("role_permissions").Where("permission_id = ?", viewSales.Id).Count(&granted)
+	if granted != 0 {
+		t.Errorf("view_sales granted again to %d roles after re-running migration", granted)
+	}
+}
+
+func TestUserGuards(t *testing.T) {
+	db := openTestDB(t)
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	var adminPermissions, managerPermissions []models.Permission
+	db.Where("name IN ?", []string{"edit_roles", "edit_users"}).Find(&adminPermissions)
+	db.Where("name = ?", "edit_users").Find(&managerPermissions)
+	adminToken := createTestUser(t, db, "admin", 0, adminPermissions)
+	managerToken := createTestUser(t, db, "manager", 1, managerPermissions)
+
+	var admin models.User
+	db.Where("email = ?", "admin0@example.com").First(&admin)
+	deleted := models.User{FirstName: "deleted", Email: "deleted@example.com"}
+	db.Create(&deleted)
+	db.Delete(&deleted)
+
+	var manager models.User
+	db.Where("email = ?", "manager1@example.com").First(&manager)
+	managerRoleBody := `{"role_id":` + strconv.Itoa(int(manager.RoleId)) + `}`
+
+	app := newTestApp(t, db)
+	adminPath := "/api/users/" + strconv.Itoa(int(admin.Id))
+
+	cases := []struct {
+		name, token, method, path, body string
+		status                          int
+	}{
+		{"delete self", adminToken, "DELETE", adminPath, "", http.StatusBadRequest},
+		{"deactivate self", adminToken, "POST", adminPath + "/deactivate", "", http.StatusBadRequest},
+		{"delete last role admin", managerToken, "DELETE", adminPath, "", http.StatusConflict},
+		{"deactivate last role admin", managerToken, "POST", adminPath + "/deactivate", "", http.StatusConflict},
+		{"demote self as last role admin", adminToken, "PUT", adminPath, managerRoleBody, http.StatusConflict},
+		{"demote last role admin", managerToken, "PUT", adminPath, managerRoleBody, http.StatusConflict},
+		{"reuse deleted email", managerToken, "POST", "/api/users", `{"first_name":"new","email":"DELETED@example.com"}`, http.StatusConflict},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "jwt", Value: tc.token})
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.status, resp.StatusCode)
+		}
+	}
+}
+
+func TestVariantResponsesHideCost(t *testing.T) {
+	db := openTestDB(t)
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	var editor, costViewer []models.Permission
+	db.Where("name = ?", "edit_products").Find(&editor)
+	db.Where("name IN ?", []string{"edit_products", "view_cost"}).Find(&costViewer)
+	editorToken := createTestUser(t, db, "editor", 0, editor)
+	costToken := createTestUser(t, db, "cost_viewer", 1, costViewer)
+
+	product := models.Product{Barcode: "P-1", Title: "Kaos", Options: []string{"size"}}
+	db.Create(&product)
+	app := newTestApp(t, db)
+	path := "/api/products/" + strconv.Itoa(int(product.ID)) + "/variants"
+
+	cases := []struct {
+		name, token, method, path, body string
+		showsCost                       bool
+	}{
+		{"create without view_cost", editorToken, "POST", path, `{"barcode":"V-S","price":5000,"options":{"size":"S"}}`, false},
+		{"update without view_cost", editorToken, "PUT", path + "/1", `{"barcode":"V-S","price":6000,"options":{"size":"S"}}`, false},
+		{"create with view_cost", costToken, "POST", path, `{"barcode":"V-M","price":5000,"options":{"size":"M"}}`, true},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "jwt", Value: tc.token})
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("%s: decode response: %v", tc.name, err)
+		}
+		if resp.StatusCode >= 300 {
+			t.Fatalf("%s: status %d: %v", tc.name, resp.StatusCode, body)
+		}
+		if _, ok := body["price"]; ok != tc.showsCost {
+			t.Errorf("%s: price present = %v, want %v", tc.name, ok, tc.showsCost)
+		}
+	}
+}
+
+// expectedStatus mengembalikan 200 jika request harus sampai ke handler.
+func expectedStatus(rc routeCase, role testRole) int {
+	if rc.permission == public {
+		return http.StatusOK
+	}
+	if role.name == "anonymous" {
+		return http.StatusUnauthorized
+	}
+	if rc.permission == self {
+		return http.StatusOK
+	}
+	for _, p := range role.permissions {
+		if p == rc.permission {
+			return http.StatusOK
+		}
+	}
+	return http.StatusForbidden
+}
+
+// requestPath mengganti parameter route dengan ID yang tidak ada,
+// supaya handler yang lolos tidak mengubah data fixture.
+func requestPath(path string) string {
+	return routeParam.ReplaceAllString(path, "999999")
+}
+
+func TestProductImageUrlsUseProxy(t *testing.T) {
+	db := openTestDB(t)
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	var viewer []models.Permission
+	db.Where("name = ?", "view_products").Find(&viewer)
+	token := createTestUser(t, db, "viewer", 0, viewer)
+
+	product := models.Product{Barcode: "P-1", Title: "Kaos", ImageKey: "kaos.jpg"}
+	db.Create(&product)
+	db.Create(&models.ProductImage{ProductID: product.ID, IsPrimary: true,
+		OriginalKey: "kaos.jpg", MediumKey: "kaos-medium.jpg", ThumbnailKey: "kaos-thumb.jpg"})
+
+	// Bucket palsu yang hanya melayani object dengan nama persis
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["location"]; ok {
+			w.Write([]byte(`<LocationConstraint>us-east-1</LocationConstraint>`))
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/products/")
+		if key != "kaos-thumb.jpg" && key != "kaos.jpg" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+			return
+		}
+		w.Header().Set("ETag", `"`+key+`"`)
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+		w.Write([]byte(key))
+	}))
+	defer storage.Close()
+
+	minioService, err := service.NewMinioService(strings.TrimPrefix(storage.URL, "http://"), "test", "test", "products", false)
+	if err != nil {
+		t.Fatalf("create minio service: %v", err)
+	}
+	app := fiber.New()
+	Setup(app, db, minioService)
+
+	get := func(path string) *http.Response {
+		req := httptest.NewRequest("GET", path, nil)
+		req.AddCookie(&http.Cookie{Name: "jwt", Value: token})
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("request %s: %v", path, err)
+		}
+		return resp
+	}
+
+	resp := get("/api/products/" + strconv.Itoa(int(product.ID)))
+	var body struct {
+		ImgUrl       string `json:"img_url"`
+		ThumbnailUrl string `json:"thumbnail_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode product: %v", err)
+	}
+
+	for url, want := range map[string]string{body.ImgUrl: "kaos.jpg", body.ThumbnailUrl: "kaos-thumb.jpg"} {
+		if !strings.HasPrefix(url, "/api/products/") {
+			t.Errorf("url %q does not point to the image proxy", url)
+			continue
+		}
+		resp := get(url)
+		data, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(data) != want {
+			t.Errorf("%s: status %d, body %q, want %q", url, resp.StatusCode, data, want)
+		}
+	}
+}
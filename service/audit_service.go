@@ -0,0 +1,86 @@
+package service
+
+import (
+	"go-admin/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// AuditExportLimit membatasi jumlah baris pada export audit log.
+const AuditExportLimit = 10000
+
+type AuditService struct {
+	db *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+func (s *AuditService) GetEvents(query models.ListQuery) (fiber.Map, error) {
+	return models.Paginate(s.db, &models.AuditEvent{}, query)
+}
+
+// ExportExcel memakai filter yang sama dengan GetEvents, tanpa paging.
+func (s *AuditService) ExportExcel(query models.ListQuery) (*excelize.File, error) {
+	event := &models.AuditEvent{}
+	db, err := query.Filter(s.db, event.ListSchema())
+	if err != nil {
+		return nil, err
+	}
+	data, err := event.Take(db.Order("created_at DESC, id DESC"), AuditExportLimit, 0)
+	if err != nil {
+		return nil, err
+	}
+	events := data.([]models.AuditEvent)
+
+	f := excelize.NewFile()
+	sheet := "Audit Log"
+	f.NewSheet(sheet)
+	f.DeleteSheet("Sheet1")
+
+	header := []string{"Date", "Actor", "Action", "Entity", "Entity ID", "Before", "After", "IP", "Request ID"}
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#E6E6E7"}, Pattern: 1},
+	})
+	for i, h := range header {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+	}
+
+	for i, event := range events {
+		actor := "System"
+		if event.Actor != nil {
+			actor = event.Actor.FirstName + " " + event.Actor.LastName
+		}
+
+		data := []interface{}{
+			event.CreatedAt.Format("2006-01-02 15:04:05"),
+			actor,
+			event.Action,
+			event.EntityType,
+			event.EntityID,
+			string(event.Before),
+			string(event.After),
+			event.IP,
+			event.RequestID,
+		}
+		for j, d := range data {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+2)
+			f.SetCellValue(sheet, cell, d)
+		}
+	}
+
+	for i := range header {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		f.SetColWidth(sheet, col, col, 20)
+	}
+	f.SetColWidth(sheet, "F", "G", 60)
+
+	return f, nil
+}
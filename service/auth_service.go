@@ -1,13 +1,18 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"go-admin/database"
+	"go-admin/dto"
 	"go-admin/models"
 	"go-admin/util"
 	"gorm.io/gorm"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type AuthService struct {
@@ -18,6 +23,10 @@ func NewAuthService() *AuthService {
 	return &AuthService{db: database.DB}
 }
 
+func (s *AuthService) WithContext(ctx context.Context) *AuthService {
+	return &AuthService{db: s.db.WithContext(ctx)}
+}
+
 func (s *AuthService) Register(data map[string]string) (*models.User, error) {
 	if data["password"] != data["password_confirm"] {
 		return nil, errors.New("passwords do not match")
@@ -29,6 +38,9 @@ func (s *AuthService) Register(data map[string]string) (*models.User, error) {
 		Email:     data["email"],
 		RoleId:    1,
 	}
+	if err := checkEmailAvailable(s.db, user.Email, 0); err != nil {
+		return nil, err
+	}
 	user.SetPassword(data["password"])
 
 	if err := s.db.Create(user).Error; err != nil {
@@ -48,6 +60,10 @@ func (s *AuthService) Login(data map[string]string) (*models.User, string, error
 		return nil, "", errors.New("incorrect password")
 	}
 
+	if !user.IsActive() {
+		return nil, "", errors.New("user is inactive")
+	}
+
 	token, err := util.GenerateJwt(strconv.Itoa(int(user.Id)))
 	if err != nil {
 		return nil, "", errors.New("failed to generate token")
@@ -103,5 +119,98 @@ func (s *AuthService) UpdatePassword(id string, data map[string]string) error {
 		return errors.New("password update failed")
 	}
 
+	// Hash password tidak dikirim ke audit log, hanya kejadiannya
+	if err := database.RecordAudit(s.db, "password_change", user.AuditEntity(), user.Id, nil, nil); err != nil {
+		return err
+	}
+
 	return nil
-}
\ No newline at end of file
+}
+
+// GetProfile mengembalikan profil user yang sudah dinormalisasi: nama
+// permission efektif, aksi per resource, menu yang boleh diakses, dan
+// preferensi user.
+func (s *AuthService) GetProfile(id string) (*dto.UserProfile, error) {
+	user, err := s.GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make([]string, 0, len(user.Role.Permissions))
+	allowed := make(map[string]bool, len(user.Role.Permissions))
+	actions := make(map[string][]string)
+	for _, p := range user.Role.Permissions {
+		if allowed[p.Name] {
+			continue
+		}
+		allowed[p.Name] = true
+		permissions = append(permissions, p.Name)
+
+		if action, resource, ok := strings.Cut(p.Name, "_"); ok {
+			actions[resource] = append(actions[resource], action)
+		}
+	}
+	sort.Strings(permissions)
+	for resource := range actions {
+		sort.Strings(actions[resource])
+	}
+
+	return &dto.UserProfile{
+		ID:        user.Id,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		Role: dto.ProfileRole{
+			ID:        user.Role.Id,
+			Name:      user.Role.Name,
+			DataScope: user.Role.DataScope,
+		},
+		Permissions: permissions,
+		Actions:     actions,
+		Navigation:  filterNavigation(navigation, allowed),
+		Preferences: dto.UserPreferences{
+			Language: user.Language,
+			Timezone: user.Timezone,
+			PageSize: user.PageSize,
+		},
+	}, nil
+}
+
+var supportedLanguages = map[string]bool{"id": true, "en": true}
+
+func (s *AuthService) UpdatePreferences(id string, prefs dto.UserPreferences) (*dto.UserPreferences, error) {
+	userId, _ := strconv.Atoi(id)
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if prefs.Language != "" {
+		if !supportedLanguages[prefs.Language] {
+			return nil, errors.New("unsupported language: " + prefs.Language)
+		}
+		user.Language = prefs.Language
+	}
+	if prefs.Timezone != "" {
+		if _, err := time.LoadLocation(prefs.Timezone); err != nil {
+			return nil, errors.New("invalid timezone: " + prefs.Timezone)
+		}
+		user.Timezone = prefs.Timezone
+	}
+	if prefs.PageSize != 0 {
+		if prefs.PageSize < 1 || prefs.PageSize > models.MaxPageSize {
+			return nil, fmt.Errorf("page size must be between 1 and %d", models.MaxPageSize)
+		}
+		user.PageSize = prefs.PageSize
+	}
+
+	if err := s.db.Model(&user).Select("language", "timezone", "page_size").Updates(&user).Error; err != nil {
+		return nil, errors.New("update failed")
+	}
+
+	return &dto.UserPreferences{
+		Language: user.Language,
+		Timezone: user.Timezone,
+		PageSize: user.PageSize,
+	}, nil
+}
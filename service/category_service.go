@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-admin/dto"
+	"go-admin/models"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrTagNotFound      = errors.New("tag not found")
+)
+
+type CategoryService struct {
+	db *gorm.DB
+}
+
+func NewCategoryService(db *gorm.DB) *CategoryService {
+	return &CategoryService{db: db}
+}
+
+func (s *CategoryService) WithContext(ctx context.Context) *CategoryService {
+	return &CategoryService{db: s.db.WithContext(ctx)}
+}
+
+// GetCategoryTree mengembalikan semua kategori sebagai pohon, diurutkan
+// berdasarkan nama di setiap tingkat.
+func (s *CategoryService) GetCategoryTree() ([]models.Category, error) {
+	var categories []models.Category
+	if err := s.db.Order("name, id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint][]models.Category)
+	roots := []models.Category{}
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	var build func(nodes []models.Category) []models.Category
+	build = func(nodes []models.Category) []models.Category {
+		for i := range nodes {
+			nodes[i].Children = build(children[nodes[i].ID])
+		}
+		return nodes
+	}
+	return build(roots), nil
+}
+
+func (s *CategoryService) CreateCategory(request dto.CategoryRequest) (*models.Category, error) {
+	category := models.Category{Name: strings.TrimSpace(request.Name), ParentID: request.ParentID}
+	if err := validateCategory(s.db, &category); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (s *CategoryService) UpdateCategory(id uint, request dto.CategoryRequest) (*models.Category, error) {
+	var category models.Category
+	if err := s.db.First(&category, id).Error; err != nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	category.Name = strings.TrimSpace(request.Name)
+	category.ParentID = request.ParentID
+	if err := validateCategory(s.db, &category); err != nil {
+		return nil, err
+	}
+	if err := s.db.Omit("Children").Save(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// DeleteCategory menghapus kategori. Sub kategori dan produknya dipindah
+// ke kategori induk (atau tanpa kategori jika kategori ini paling atas).
+func (s *CategoryService) DeleteCategory(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		if err := tx.First(&category, id).Error; err != nil {
+			return ErrCategoryNotFound
+		}
+
+		if err := tx.Model(&models.Category{}).
+			Where("parent_id = ?", category.ID).
+			Update("parent_id", category.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Product{}).
+			Where("category_id = ?", category.ID).
+			Update("category_id", category.ParentID).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&category).Error
+	})
+}
+
+// validateCategory memastikan nama terisi, induk ada, dan induk bukan
+// kategori itu sendiri atau turunannya.
+func validateCategory(db *gorm.DB, category *models.Category) error {
+	if category.Name == "" {
+		return errors.New("name is required")
+	}
+	if category.ParentID == nil {
+		return nil
+	}
+
+	var parent models.Category
+	if err := db.First(&parent, *category.ParentID).Error; err != nil {
+		return errors.New("parent category not found")
+	}
+	if category.ID == 0 {
+		return nil
+	}
+
+	descendants, err := categoryDescendants(db, []uint{category.ID})
+	if err != nil {
+		return err
+	}
+	for _, id := range descendants {
+		if id == parent.ID {
+			return errors.New("category cannot be moved under itself or its descendants")
+		}
+	}
+	return nil
+}
+
+// categoryDescendants mengembalikan ID kategori tersebut beserta seluruh
+// turunannya.
+func categoryDescendants(db *gorm.DB, ids []uint) ([]uint, error) {
+	var categories []models.Category
+	if err := db.Select("id", "parent_id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint][]uint)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], category.ID)
+		}
+	}
+
+	seen := make(map[uint]bool)
+	result := []uint{}
+	queue := append([]uint{}, ids...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+		queue = append(queue, children[id]...)
+	}
+	return result, nil
+}
+
+// categoryPaths mengembalikan nama lengkap setiap kategori dari induk paling
+// atas, misalnya "Minuman > Kopi > Kopi Susu", supaya sub kategori dengan
+// nama sama bisa dibedakan di laporan.
+func categoryPaths(db *gorm.DB) (map[uint]string, error) {
+	var categories []models.Category
+	if err := db.Select("id", "name", "parent_id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	paths := make(map[uint]string, len(categories))
+	for _, category := range categories {
+		names := []string{category.Name}
+		seen := map[uint]bool{category.ID: true}
+		for parentID := category.ParentID; parentID != nil && !seen[*parentID]; {
+			parent, ok := byID[*parentID]
+			if !ok {
+				break
+			}
+			seen[parent.ID] = true
+			names = append([]string{parent.Name}, names...)
+			parentID = parent.ParentID
+		}
+		paths[category.ID] = strings.Join(names, " > ")
+	}
+	return paths, nil
+}
+
+// GetTags mengembalikan semua tag beserta jumlah produknya.
+func (s *CategoryService) GetTags() ([]models.Tag, error) {
+	tags := []models.Tag{}
+	if err := s.db.Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		TagID uint
+		Total int64
+	}
+	if err := s.db.Table("product_tags").
+		Select("tag_id, COUNT(*) AS total").
+		Group("tag_id").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	byTag := make(map[uint]int64, len(counts))
+	for _, c := range counts {
+		byTag[c.TagID] = c.Total
+	}
+	for i := range tags {
+		tags[i].ProductCount = byTag[tags[i].ID]
+	}
+	return tags, nil
+}
+
+func (s *CategoryService) CreateTag(request dto.TagRequest) (*models.Tag, error) {
+	name := normalizeTag(request.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := tagNameAvailable(s.db, name, 0); err != nil {
+		return nil, err
+	}
+
+	tag := models.Tag{Name: name}
+	if err := s.db.Create(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (s *CategoryService) UpdateTag(id uint, request dto.TagRequest) (*models.Tag, error) {
+	var tag models.Tag
+	if err := s.db.First(&tag, id).Error; err != nil {
+		return nil, ErrTagNotFound
+	}
+
+	name := normalizeTag(request.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := tagNameAvailable(s.db, name, tag.ID); err != nil {
+		return nil, err
+	}
+
+	tag.Name = name
+	if err := s.db.Save(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (s *CategoryService) DeleteTag(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.First(&tag, id).Error; err != nil {
+			return ErrTagNotFound
+		}
+		if err := tx.Exec("DELETE FROM product_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+}
+
+func tagNameAvailable(db *gorm.DB, name string, id uint) error {
+	var count int64
+	if err := db.Model(&models.Tag{}).Where("name = ? AND id <> ?", name, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("tag already exists")
+	}
+	return nil
+}
+
+// normalizeTag menyimpan tag dalam huruf kecil tanpa spasi berlebih,
+// sehingga "Promo " dan "promo" dianggap tag yang sama.
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// findOrCreateTags mengubah daftar nama tag menjadi models.Tag, membuat tag
+// yang belum ada.
+func findOrCreateTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	seen := make(map[string]bool)
+	tags := []models.Tag{}
+	for _, name := range names {
+		name = normalizeTag(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		tag := models.Tag{Name: name}
+		if err := tx.Where("name = ?", name).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
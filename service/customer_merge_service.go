@@ -0,0 +1,334 @@
+package service
+
+import (
+	"errors"
+	"go-admin/database"
+	"go-admin/dto"
+	"go-admin/models"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DuplicateLimit adalah jumlah maksimal pasangan duplikat yang dikembalikan.
+	DuplicateLimit = 500
+	// duplicateMinNameLength mencegah nama yang terlalu pendek dianggap mirip.
+	duplicateMinNameLength = 4
+)
+
+var ErrMergeSameCustomer = errors.New("cannot merge a customer into itself")
+
+// FindDuplicates mencari pasangan customer yang kemungkinan sama: email sama
+// (tanpa membedakan huruf besar), nomor telepon sama setelah dinormalisasi,
+// atau nama yang mirip (jarak Levenshtein kecil). Nama hanya dibandingkan
+// dengan kandidat di blok yang sama (lihat nameBlocks), bukan dengan semua
+// customer. Pasangan dengan alasan paling banyak ditampilkan lebih dulu.
+func (s *CustomerService) FindDuplicates(limit int) ([]dto.CustomerDuplicate, error) {
+	if limit < 1 {
+		limit = 100
+	}
+	if limit > DuplicateLimit {
+		limit = DuplicateLimit
+	}
+
+	var customers []models.Customer
+	if err := s.db.Order("id").Find(&customers).Error; err != nil {
+		return nil, err
+	}
+
+	type pairKey struct{ a, b int }
+	pairs := make(map[pairKey]*dto.CustomerDuplicate)
+	add := func(i, j int, reason string, distance int) {
+		key := pairKey{i, j}
+		pair, ok := pairs[key]
+		if !ok {
+			pair = &dto.CustomerDuplicate{
+				Customer:     customers[i],
+				Duplicate:    customers[j],
+				NameDistance: -1,
+			}
+			pairs[key] = pair
+		}
+		pair.Reasons = append(pair.Reasons, reason)
+		if reason == "name" {
+			pair.NameDistance = distance
+		}
+	}
+
+	// Email dan telepon dikelompokkan di map, cukup satu kali lewat
+	byKey := func(key func(models.Customer) string, reason string) {
+		seen := make(map[string][]int)
+		for i, customer := range customers {
+			k := key(customer)
+			if k == "" {
+				continue
+			}
+			for _, j := range seen[k] {
+				add(j, i, reason, 0)
+			}
+			seen[k] = append(seen[k], i)
+		}
+	}
+	byKey(func(c models.Customer) string {
+		if c.Email == nil {
+			return ""
+		}
+		return strings.ToLower(strings.TrimSpace(*c.Email))
+	}, "email")
+	byKey(func(c models.Customer) string { return normalizePhone(c.Phone) }, "phone")
+
+	names := make([][]rune, len(customers))
+	for i, customer := range customers {
+		names[i] = []rune(normalizeName(customer.Name))
+	}
+	compared := make(map[pairKey]bool)
+	for _, block := range nameBlocks(names) {
+		for x, i := range block {
+			for _, j := range block[x+1:] {
+				// Blok terurut menurut panjang nama, sisanya pasti terlalu jauh
+				if len(names[j])-len(names[i]) > 2 {
+					break
+				}
+				a, b := min(i, j), max(i, j)
+				if compared[pairKey{a, b}] {
+					continue
+				}
+				compared[pairKey{a, b}] = true
+
+				maxDistance := nameDistanceLimit(len(names[a]), len(names[b]))
+				if abs(len(names[a])-len(names[b])) > maxDistance {
+					continue
+				}
+				if d := levenshtein(names[a], names[b]); d <= maxDistance {
+					add(a, b, "name", d)
+				}
+			}
+		}
+	}
+
+	duplicates := make([]dto.CustomerDuplicate, 0, len(pairs))
+	for _, pair := range pairs {
+		duplicates = append(duplicates, *pair)
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		a, b := duplicates[i], duplicates[j]
+		if len(a.Reasons) != len(b.Reasons) {
+			return len(a.Reasons) > len(b.Reasons)
+		}
+		if a.NameDistance != b.NameDistance {
+			return a.NameDistance < b.NameDistance
+		}
+		if a.Customer.Id != b.Customer.Id {
+			return a.Customer.Id < b.Customer.Id
+		}
+		return a.Duplicate.Id < b.Duplicate.Id
+	})
+	if len(duplicates) > limit {
+		duplicates = duplicates[:limit]
+	}
+
+	return duplicates, nil
+}
+
+// MergeCustomers memindahkan transaksi, ledger poin dan piutang dari customer
+// duplikat ke customer yang dipertahankan, mengisi data kontak yang masih
+// kosong dari duplikat, lalu menghapus duplikat. Proses dicatat di audit log
+// sebagai aksi "merge" pada customer yang dipertahankan.
+func (s *CustomerService) MergeCustomers(id, duplicateID uint) (*dto.CustomerMergeResult, error) {
+	if id == duplicateID {
+		return nil, ErrMergeSameCustomer
+	}
+
+	result := &dto.CustomerMergeResult{MergedID: duplicateID}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var customer, duplicate models.Customer
+		if err := tx.First(&customer, id).Error; err != nil {
+			return ErrCustomerNotFound
+		}
+		if err := tx.First(&duplicate, duplicateID).Error; err != nil {
+			return ErrCustomerNotFound
+		}
+
+		moved := tx.Model(&models.Transaction{}).
+			Where("customer_id = ?", duplicate.Id).
+			Update("customer_id", customer.Id)
+		if moved.Error != nil {
+			return moved.Error
+		}
+		result.Transactions = moved.RowsAffected
+
+		moved = tx.Model(&models.LoyaltyEntry{}).
+			Where("customer_id = ?", duplicate.Id).
+			Update("customer_id", customer.Id)
+		if moved.Error != nil {
+			return moved.Error
+		}
+		result.LoyaltyEntries = moved.RowsAffected
+
+		moved = tx.Model(&models.Receivable{}).
+			Where("customer_id = ?", duplicate.Id).
+			Update("customer_id", customer.Id)
+		if moved.Error != nil {
+			return moved.Error
+		}
+		result.Receivables = moved.RowsAffected
+
+		// Duplikat dihapus dulu supaya email dan nomor kartunya bisa dipakai
+		if err := tx.Delete(&duplicate).Error; err != nil {
+			return err
+		}
+
+		updates := mergeCustomerFields(customer, duplicate)
+		if len(updates) > 0 {
+			if err := tx.Model(&customer).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := database.RecordAudit(tx, "merge", customer.AuditEntity(), customer.Id, duplicate, map[string]interface{}{
+			"merged_id":       duplicate.Id,
+			"transactions":    result.Transactions,
+			"loyalty_entries": result.LoyaltyEntries,
+			"receivables":     result.Receivables,
+		}); err != nil {
+			return err
+		}
+
+		if err := tx.First(&result.Customer, customer.Id).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// mergeCustomerFields mengisi field customer yang kosong dengan data duplikat.
+func mergeCustomerFields(customer, duplicate models.Customer) map[string]interface{} {
+	updates := make(map[string]interface{})
+	fill := func(column, current, value string) {
+		if strings.TrimSpace(current) == "" && strings.TrimSpace(value) != "" {
+			updates[column] = value
+		}
+	}
+	fill("name", customer.Name, duplicate.Name)
+	fill("phone", customer.Phone, duplicate.Phone)
+	fill("address", customer.Address, duplicate.Address)
+	fill("tax_id", customer.TaxID, duplicate.TaxID)
+
+	if customer.Email == nil && duplicate.Email != nil {
+		updates["email"] = duplicate.Email
+	}
+	if customer.Birthday == nil && duplicate.Birthday != nil {
+		updates["birthday"] = duplicate.Birthday
+	}
+	if customer.MemberCardNumber == nil && duplicate.MemberCardNumber != nil {
+		updates["member_card_number"] = duplicate.MemberCardNumber
+	}
+	if strings.TrimSpace(duplicate.Notes) != "" && duplicate.Notes != customer.Notes {
+		notes := duplicate.Notes
+		if strings.TrimSpace(customer.Notes) != "" {
+			notes = customer.Notes + "\n" + duplicate.Notes
+		}
+		updates["notes"] = notes
+	}
+	return updates
+}
+
+// normalizePhone menyisakan angka dan mengganti awalan 62 dengan 0,
+// sehingga +62 812-345 dan 0812345 dianggap sama.
+func normalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if strings.HasPrefix(digits, "62") {
+		digits = "0" + digits[2:]
+	}
+	if len(digits) < 6 {
+		return ""
+	}
+	return digits
+}
+
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else if unicode.IsSpace(r) {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// nameBlocks mengelompokkan nama kandidat duplikat berdasarkan dua huruf
+// pertama dan dua huruf terakhir. Satu salah ketik hanya mengubah salah
+// satunya, jadi pasangan dengan jarak 1 selalu berada di blok yang sama;
+// nama panjang dengan salah ketik di awal dan di akhir sekaligus bisa
+// terlewat. Nama yang lebih pendek dari duplicateMinNameLength diabaikan.
+// Setiap blok diurutkan menurut panjang nama.
+func nameBlocks(names [][]rune) map[string][]int {
+	blocks := make(map[string][]int)
+	for i, name := range names {
+		if len(name) < duplicateMinNameLength {
+			continue
+		}
+		prefix := "^" + string(name[:2])
+		suffix := string(name[len(name)-2:]) + "$"
+		blocks[prefix] = append(blocks[prefix], i)
+		blocks[suffix] = append(blocks[suffix], i)
+	}
+	for _, block := range blocks {
+		sort.SliceStable(block, func(x, y int) bool {
+			return len(names[block[x]]) < len(names[block[y]])
+		})
+	}
+	return blocks
+}
+
+// nameDistanceLimit: satu salah ketik untuk nama pendek, dua untuk nama
+// yang lebih panjang dari 10 huruf.
+func nameDistanceLimit(a, b int) int {
+	if a > 10 && b > 10 {
+		return 2
+	}
+	return 1
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -1,11 +1,20 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"github.com/gofiber/fiber/v2"
+	"go-admin/dto"
 	"go-admin/models"
 	"gorm.io/gorm"
-	"math"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+var (
+	ErrCustomerNotFound       = errors.New("customer not found")
+	ErrCustomerHasReceivables = errors.New("customer has unsettled receivables")
+	ErrCustomerHasHistory     = errors.New("customer has transactions or loyalty points; merge it into another customer instead")
 )
 
 type CustomerService struct {
@@ -16,42 +25,101 @@ func NewCustomerService(db *gorm.DB) *CustomerService {
 	return &CustomerService{db: db}
 }
 
+// WithContext mengembalikan salinan service yang membawa context request,
+// dipakai agar perubahan data tercatat di audit log beserta pelakunya.
+func (s *CustomerService) WithContext(ctx context.Context) *CustomerService {
+	return &CustomerService{db: s.db.WithContext(ctx)}
+}
+
 func (s *CustomerService) DropdownCustomers() ([]models.Customer, error) {
 	var customers []models.Customer
 	result := s.db.Find(&customers)
 	return customers, result.Error
 }
 
-func (s *CustomerService) AllCustomers(page int) fiber.Map {
-	limit := 5
-	offset := (page - 1) * limit
-	var customers []models.Customer
-	s.db.Offset(offset).Limit(limit).Find(&customers)
+func (s *CustomerService) AllCustomers(query models.ListQuery) (fiber.Map, error) {
+	return models.Paginate(s.db, &models.Customer{}, query)
+}
 
-	var total int64
-	s.db.Model(&models.Customer{}).Count(&total)
+// LookupLimit adalah jumlah maksimal hasil typeahead customer.
+const LookupLimit = 50
 
-	lastPage := math.Ceil(float64(total) / float64(limit))
-	return fiber.Map{
-		"data": customers,
-		"meta": fiber.Map{
-			"total":     total,
-			"page":      page,
-			"last_page": lastPage,
-		},
+// LookupCustomers mencari customer untuk typeahead kasir berdasarkan awalan
+// nama, telepon atau email, atau nomor kartu member yang persis. Semua
+// kondisi berupa prefix supaya bisa memakai index dari createLookupIndexes.
+// Hasil yang cocok persis dengan nomor kartu atau telepon ditampilkan paling
+// atas. Setiap customer membawa sisa piutang dan tanda overdue.
+func (s *CustomerService) LookupCustomers(q string, limit int) ([]models.Customer, error) {
+	customers := []models.Customer{}
+
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return customers, nil
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > LookupLimit {
+		limit = LookupLimit
 	}
+
+	prefix := escapeLike(q) + "%"
+	lowerPrefix := strings.ToLower(prefix)
+	err := s.db.
+		Select("id", "name", "email", "phone", "member_card_number", "credit_limit", "payment_term_days").
+		Where(`LOWER(name) LIKE ? ESCAPE '\' OR phone LIKE ? ESCAPE '\' OR LOWER(email) LIKE ? ESCAPE '\' OR member_card_number = ?`,
+			lowerPrefix, prefix, lowerPrefix, q).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  `CASE WHEN member_card_number = ? OR phone = ? THEN 0 WHEN LOWER(name) LIKE ? ESCAPE '\' THEN 1 ELSE 2 END, name`,
+			Vars: []interface{}{q, q, lowerPrefix},
+		}}).
+		Limit(limit).
+		Find(&customers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return customers, attachCredit(s.db, customers)
+}
+
+// escapeLike meng-escape karakter wildcard LIKE supaya input pencarian
+// dicocokkan apa adanya. Dipakai bersama ESCAPE '\'.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
 }
 
 func (s *CustomerService) CreateCustomer(customer *models.Customer) error {
+	normalizeCustomer(customer)
 	result := s.db.Create(customer)
 	return result.Error
 }
 
+// normalizeCustomer membuang spasi dan mengosongkan email serta nomor kartu
+// member yang kosong, supaya tidak bentrok dengan unique index.
+func normalizeCustomer(customer *models.Customer) {
+	customer.Name = strings.TrimSpace(customer.Name)
+	customer.Email = trimOptional(customer.Email)
+	customer.Phone = strings.TrimSpace(customer.Phone)
+	customer.MemberCardNumber = trimOptional(customer.MemberCardNumber)
+}
+
+// trimOptional membuang spasi dan mengubah string kosong menjadi nil.
+func trimOptional(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
 func (s *CustomerService) GetCustomer(id uint) (models.Customer, error) {
 	var customer models.Customer
 	result := s.db.First(&customer, id)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return models.Customer{}, errors.New("customer not found")
+		return models.Customer{}, ErrCustomerNotFound
 	}
 	return customer, result.Error
 }
@@ -61,13 +129,122 @@ func (s *CustomerService) UpdateCustomer(id uint, updatedCustomer *models.Custom
 	if err := s.db.First(&customer, id).Error; err != nil {
 		return err
 	}
+	normalizeCustomer(updatedCustomer)
 	return s.db.Model(&customer).Updates(updatedCustomer).Error
 }
 
+// DeleteCustomer menghapus customer yang belum punya riwayat. Customer dengan
+// piutang yang belum lunas, transaksi atau poin loyalty ditolak supaya
+// piutang dan riwayatnya tidak kehilangan pemilik; duplikat digabung lewat
+// MergeCustomers. Baris customer dikunci supaya penjualan yang berjalan
+// bersamaan tidak lolos dari pengecekan.
 func (s *CustomerService) DeleteCustomer(id uint) error {
-	result := s.db.Delete(&models.Customer{}, id)
-	if result.RowsAffected == 0 {
-		return errors.New("customer not found")
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCustomer(tx, id); err != nil {
+			return ErrCustomerNotFound
+		}
+		var customer models.Customer
+		if err := tx.First(&customer, id).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.Receivable{}).
+			Where("customer_id = ? AND settled_at IS NULL", id).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCustomerHasReceivables
+		}
+
+		for _, model := range []interface{}{&models.Transaction{}, &models.LoyaltyEntry{}} {
+			if err := tx.Model(model).Where("customer_id = ?", id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrCustomerHasHistory
+			}
+		}
+
+		return tx.Delete(&customer).Error
+	})
+}
+
+// CustomerTransactions mengembalikan riwayat transaksi customer beserta
+// detail barangnya, dengan paging (atau cursor) dari models.ListQuery.
+func (s *CustomerService) CustomerTransactions(id uint, scope models.DataScope, query models.ListQuery) (fiber.Map, error) {
+	if _, err := s.GetCustomer(id); err != nil {
+		return nil, err
 	}
-	return result.Error
+
+	db := s.db.
+		Preload("TransactionDetails.Product").
+		Preload("TransactionDetails.Variant").
+		Scopes(scope.Transactions("user_id")).
+		Where("customer_id = ?", id)
+
+	return models.Paginate(db, &models.Transaction{}, query)
+}
+
+// TopProductsLimit adalah jumlah produk terlaris pada ringkasan customer.
+const TopProductsLimit = 5
+
+func (s *CustomerService) CustomerSummary(id uint, scope models.DataScope) (*dto.CustomerSummary, error) {
+	if _, err := s.GetCustomer(id); err != nil {
+		return nil, err
+	}
+
+	transactions := func() *gorm.DB {
+		return s.db.Model(&models.Transaction{}).
+			Scopes(scope.Transactions("user_id"), models.NotVoided("voided_at")).
+			Where("customer_id = ?", id)
+	}
+
+	summary := dto.CustomerSummary{CustomerID: id, TopProducts: []dto.CustomerTopProduct{}}
+
+	var totals struct {
+		TotalSpent float64
+		VisitCount int64
+	}
+	err := transactions().
+		Select("COALESCE(SUM(grand_total), 0) as total_spent, COUNT(*) as visit_count").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	summary.TotalSpent = totals.TotalSpent
+	summary.VisitCount = totals.VisitCount
+
+	if summary.VisitCount == 0 {
+		return &summary, nil
+	}
+	summary.AverageBasket = summary.TotalSpent / float64(summary.VisitCount)
+
+	var first, last models.Transaction
+	if err := transactions().Select("created_at").Order("created_at ASC").Take(&first).Error; err != nil {
+		return nil, err
+	}
+	if err := transactions().Select("created_at").Order("created_at DESC").Take(&last).Error; err != nil {
+		return nil, err
+	}
+	summary.FirstPurchase = &first.CreatedAt
+	summary.LastPurchase = &last.CreatedAt
+
+	err = s.db.Table("transaction_details").
+		Select("transaction_details.product_id as product_id, products.title as title, "+
+			"SUM(transaction_details.qty) as qty, SUM(transaction_details.qty * transaction_details.price) as total").
+		Joins("join transactions on transactions.id = transaction_details.transaction_id").
+		Joins("left join products on products.id = transaction_details.product_id").
+		Scopes(scope.Transactions("transactions.user_id"), models.NotVoided("transactions.voided_at")).
+		Where("transactions.customer_id = ?", id).
+		Group("transaction_details.product_id, products.title").
+		Order("qty DESC").
+		Limit(TopProductsLimit).
+		Scan(&summary.TopProducts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
 }
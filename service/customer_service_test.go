@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"go-admin/models"
+)
+
+func TestCustomersWithoutEmail(t *testing.T) {
+	db := openTestDB(t)
+	service := NewCustomerService(db)
+
+	for _, name := range []string{"Budi", "Siti"} {
+		empty := " "
+		if err := service.CreateCustomer(&models.Customer{Name: name, Email: &empty}); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+}
+
+func TestLookupCustomersEscapesWildcards(t *testing.T) {
+	db := openTestDB(t)
+	service := NewCustomerService(db)
+	for _, name := range []string{"Budi", "Bu_di", "50% Diskon"} {
+		if err := service.CreateCustomer(&models.Customer{Name: name}); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+
+	cases := map[string]int{"%": 0, "bu_": 1, "50%": 1, "bu": 2}
+	for q, want := range cases {
+		customers, err := service.LookupCustomers(q, 10)
+		if err != nil {
+			t.Fatalf("lookup %q: %v", q, err)
+		}
+		if len(customers) != want {
+			t.Errorf("lookup %q: %d customers, want %d", q, len(customers), want)
+		}
+	}
+}
+
+func TestDeleteCustomerKeepsHistory(t *testing.T) {
+	db := openTestDB(t)
+	service := NewCustomerService(db)
+
+	debtor := models.Customer{Name: "Budi"}
+	member := models.Customer{Name: "Siti"}
+	walkIn := models.Customer{Name: "Andi"}
+	for _, customer := range []*models.Customer{&debtor, &member, &walkIn} {
+		db.Create(customer)
+	}
+	db.Create(&models.Receivable{CustomerID: debtor.Id, TransactionID: 1, Amount: 100})
+	db.Create(&models.LoyaltyEntry{CustomerID: member.Id, Type: models.LoyaltyEarn, Points: 5, Remaining: 5})
+
+	if err := service.DeleteCustomer(debtor.Id); !errors.Is(err, ErrCustomerHasReceivables) {
+		t.Errorf("delete debtor: err = %v, want %v", err, ErrCustomerHasReceivables)
+	}
+	if err := service.DeleteCustomer(member.Id); !errors.Is(err, ErrCustomerHasHistory) {
+		t.Errorf("delete member: err = %v, want %v", err, ErrCustomerHasHistory)
+	}
+	if err := service.DeleteCustomer(walkIn.Id); err != nil {
+		t.Errorf("delete customer without history: %v", err)
+	}
+	if err := service.DeleteCustomer(walkIn.Id); !errors.Is(err, ErrCustomerNotFound) {
+		t.Errorf("delete twice: err = %v, want %v", err, ErrCustomerNotFound)
+	}
+}
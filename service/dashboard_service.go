@@ -21,11 +21,15 @@ type ChartData struct {
 }
 
 type BestProduct struct {
-	Title string  `json:"title"`
-	Total float64 `json:"total"`
+	Title      string  `json:"title"`
+	CategoryID *uint   `json:"category_id"`
+	Category   string  `json:"category"`
+	Total      float64 `json:"total"`
 }
 
-func (s *DashboardService) GetDashboardData() (map[string]interface{}, error) {
+// GetDashboardData mengembalikan ringkasan dashboard. Jika showCost false,
+// profit dan harga beli produk tidak ikut dikirim.
+func (s *DashboardService) GetDashboardData(scope models.DataScope, showCost bool) (map[string]interface{}, error) {
 	now := time.Now()
 	last7Days := now.AddDate(0, 0, -7)
 
@@ -34,6 +38,7 @@ func (s *DashboardService) GetDashboardData() (map[string]interface{}, error) {
 	err := s.db.
 		Table("transactions").
 		Select("DATE(created_at) as date, SUM(grand_total) as grand_total").
+		Scopes(scope.Transactions("user_id"), models.NotVoided("voided_at")).
 		Where("created_at >= ?", last7Days).
 		Group("DATE(created_at)").
 		Scan(&chartSales).Error
@@ -55,50 +60,74 @@ func (s *DashboardService) GetDashboardData() (map[string]interface{}, error) {
 
 	// Count sales today
 	var countSalesToday int64
-	s.db.Model(&models.Transaction{}).Where("created_at::date = CURRENT_DATE").Count(&countSalesToday)
+	s.db.Model(&models.Transaction{}).Scopes(scope.Transactions("user_id"), models.NotVoided("voided_at")).Where("created_at::date = CURRENT_DATE").Count(&countSalesToday)
 
 	// Sum sales today
 	var sumSalesToday float64
-	s.db.Model(&models.Transaction{}).Select("SUM(grand_total)").Where("created_at::date = CURRENT_DATE").Scan(&sumSalesToday)
-
-	// Sum profits today
-	var sumProfitsToday float64
-	s.db.Model(&models.Profit{}).Select("SUM(total)").Where("created_at::date = CURRENT_DATE").Scan(&sumProfitsToday)
+	s.db.Model(&models.Transaction{}).Select("SUM(grand_total)").Scopes(scope.Transactions("user_id"), models.NotVoided("voided_at")).Where("created_at::date = CURRENT_DATE").Scan(&sumSalesToday)
 
 	// Products with low stock
 	var productsLimitStock []models.Product
 	s.db.Where("stock <= ?", 10).Find(&productsLimitStock)
+	if !showCost {
+		for i := range productsLimitStock {
+			productsLimitStock[i].HideCost()
+		}
+	}
 
 	// Best selling products
 	var bestProducts []BestProduct
 	s.db.Table("transaction_details").
-		Select("products.title as title, SUM(transaction_details.qty) as total").
+		Select("products.title as title, products.category_id as category_id, SUM(transaction_details.qty) as total").
 		Joins("join products on products.id = transaction_details.product_id").
-		Group("transaction_details.product_id, products.title").
+		Joins("join transactions on transactions.id = transaction_details.transaction_id").
+		Scopes(scope.Transactions("transactions.user_id"), models.NotVoided("transactions.voided_at")).
+		Group("transaction_details.product_id, products.title, products.category_id").
 		Order("total DESC").
 		Limit(5).
 		Scan(&bestProducts)
 
-	var productTitles []string
+	categories, err := categoryPaths(s.db)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range bestProducts {
+		if p.CategoryID != nil {
+			bestProducts[i].Category = categories[*p.CategoryID]
+		}
+	}
+
+	var productTitles, productCategories []string
 	var totalQty []float64
 	if len(bestProducts) > 0 {
 		for _, p := range bestProducts {
 			productTitles = append(productTitles, p.Title)
+			productCategories = append(productCategories, p.Category)
 			totalQty = append(totalQty, p.Total)
 		}
 	} else {
 		productTitles = []string{""}
+		productCategories = []string{""}
 		totalQty = []float64{0}
 	}
 
-	return map[string]interface{}{
+	data := map[string]interface{}{
 		"sales_date":           salesDate,
 		"grand_total":          grandTotal,
 		"count_sales_today":    countSalesToday,
 		"sum_sales_today":      sumSalesToday,
-		"sum_profits_today":    sumProfitsToday,
 		"products_limit_stock": productsLimitStock,
 		"product":              productTitles,
+		"category":             productCategories,
 		"total":                totalQty,
-	}, nil
+	}
+
+	// Sum profits today
+	if showCost {
+		var sumProfitsToday float64
+		s.db.Model(&models.Profit{}).Select("SUM(total)").Scopes(scope.Profits).Where("created_at::date = CURRENT_DATE").Scan(&sumProfitsToday)
+		data["sum_profits_today"] = sumProfitsToday
+	}
+
+	return data, nil
 }
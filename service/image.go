@@ -0,0 +1,156 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// MaxImageSize adalah ukuran file gambar maksimum yang boleh diunggah.
+	MaxImageSize = 5 << 20
+	// MaxImageDimension adalah lebar/tinggi maksimum gambar dalam pixel.
+	MaxImageDimension = 8000
+	// MaxImagePixels membatasi total pixel supaya gambar kecil berukuran
+	// dimensi besar tidak menghabiskan memori saat di-decode.
+	MaxImagePixels = 40_000_000
+)
+
+var (
+	ErrInvalidImage     = errors.New("invalid image")
+	ErrUnsupportedImage = errors.New("unsupported image type, allowed: jpeg, png, webp")
+	ErrImageTooLarge    = fmt.Errorf("image is larger than %d MB", MaxImageSize>>20)
+)
+
+// allowedImageTypes berisi content type hasil sniffing yang boleh diunggah.
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// imageExtensions dipakai untuk nama object di MinIO. Hanya file hasil
+// encode ulang (jpeg/png) yang disimpan.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// decodeUpload membaca gambar dari upload user. Jenis file ditentukan dari
+// isi file (magic bytes), bukan dari header Content-Type yang dikirim client.
+func decodeUpload(r io.Reader) (image.Image, string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxImageSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > MaxImageSize {
+		return nil, "", ErrImageTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowedImageTypes[contentType] {
+		return nil, "", ErrUnsupportedImage
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width < 1 || config.Height < 1 ||
+		config.Width > MaxImageDimension || config.Height > MaxImageDimension ||
+		config.Width*config.Height > MaxImagePixels {
+		return nil, "", fmt.Errorf("%w: dimensions %dx%d exceed the limit of %dx%d",
+			ErrInvalidImage, config.Width, config.Height, MaxImageDimension, MaxImageDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	return img, contentType, nil
+}
+
+// sanitizeImage meng-encode ulang gambar sehingga metadata seperti EXIF/GPS
+// tidak ikut tersimpan. PNG tetap PNG; WebP disimpan sebagai PNG jika punya
+// transparansi dan JPEG jika tidak.
+func sanitizeImage(img image.Image, contentType string) ([]byte, string, error) {
+	opaque, ok := img.(interface{ Opaque() bool })
+	if contentType == "image/png" || (contentType == "image/webp" && ok && !opaque.Opaque()) {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+
+	data, err := encodeJPEG(img)
+	return data, "image/jpeg", err
+}
+
+// squareThumbnail memotong bagian tengah gambar menjadi persegi lalu
+// mengecilkannya ke ukuran size x size dalam format JPEG.
+func squareThumbnail(r io.Reader, size int) ([]byte, error) {
+	src, _, err := decodeUpload(r)
+	if err != nil {
+		return nil, err
+	}
+	return encodeJPEG(cropSquare(src, size))
+}
+
+func cropSquare(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	crop := image.Rect(x0, y0, x0+side, y0+side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+	return dst
+}
+
+// fitImage mengecilkan gambar supaya muat di dalam max x max tanpa mengubah
+// rasio. Gambar yang sudah lebih kecil tidak diperbesar.
+func fitImage(src image.Image, max int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= max && h <= max {
+		return src
+	}
+	if w >= h {
+		h = h * max / w
+		w = max
+	} else {
+		w = w * max / h
+		h = max
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"go-admin/dto"
+	"go-admin/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultImageGCGracePeriod melindungi file yang baru diunggah tapi belum
+// tersimpan di database, misalnya upload yang masih berjalan.
+const DefaultImageGCGracePeriod = 24 * time.Hour
+
+type ImageGCService struct {
+	db          *gorm.DB
+	minioClient *MinioService
+}
+
+func NewImageGCService(db *gorm.DB, minioClient *MinioService) *ImageGCService {
+	return &ImageGCService{db: db, minioClient: minioClient}
+}
+
+type imageReference struct {
+	entity string
+	id     uint
+	key    string
+}
+
+// Run membandingkan isi bucket dengan key gambar di database. Object yang
+// tidak dipakai dan lebih tua dari grace period dilaporkan sebagai orphan
+// (dan dihapus jika opts.Delete); key yang dipakai tapi tidak ada di bucket
+// dilaporkan sebagai missing.
+func (s *ImageGCService) Run(ctx context.Context, opts dto.ImageGCOptions) (*dto.ImageGCResult, error) {
+	// Referensi diambil sebelum list bucket, supaya upload yang selesai di
+	// antara keduanya tetap terlindungi grace period
+	references, err := s.imageReferences()
+	if err != nil {
+		return nil, err
+	}
+	objects, err := s.minioClient.ListFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool, len(references))
+	for _, ref := range references {
+		referenced[ref.key] = true
+	}
+
+	result := dto.ImageGCResult{
+		Objects:    len(objects),
+		Referenced: len(referenced),
+		Orphans:    []dto.OrphanImage{},
+		Missing:    []dto.MissingImage{},
+	}
+
+	stored := make(map[string]bool, len(objects))
+	cutoff := time.Now().Add(-opts.GracePeriod)
+	for _, obj := range objects {
+		stored[obj.Key] = true
+		if referenced[obj.Key] {
+			continue
+		}
+		if obj.LastModified.After(cutoff) {
+			result.Recent++
+			continue
+		}
+
+		result.Orphans = append(result.Orphans, dto.OrphanImage{
+			Key:          obj.Key,
+			Size:         obj.Size,
+			LastModified: obj.LastModified,
+		})
+		if opts.Delete {
+			if err := s.minioClient.DeleteFile(obj.Key); err != nil {
+				return &result, err
+			}
+			result.Deleted++
+		}
+	}
+
+	reported := make(map[imageReference]bool)
+	for _, ref := range references {
+		if !stored[ref.key] && !reported[ref] {
+			reported[ref] = true
+			result.Missing = append(result.Missing, dto.MissingImage{
+				Entity:   ref.entity,
+				EntityID: ref.id,
+				Key:      ref.key,
+			})
+		}
+	}
+	sort.Slice(result.Missing, func(i, j int) bool {
+		if result.Missing[i].Entity != result.Missing[j].Entity {
+			return result.Missing[i].Entity < result.Missing[j].Entity
+		}
+		return result.Missing[i].EntityID < result.Missing[j].EntityID
+	})
+
+	return &result, nil
+}
+
+// imageReferences mengumpulkan semua key object yang dipakai di database.
+// User yang dihapus (soft delete) tetap dihitung karena masih bisa di-restore.
+func (s *ImageGCService) imageReferences() ([]imageReference, error) {
+	var references []imageReference
+
+	var products []models.Product
+	if err := s.db.Select("id", "image_key").Where("image_key <> ''").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range products {
+		references = append(references, imageReference{"product", p.ID, models.ObjectKey(p.ImageKey)})
+	}
+
+	var images []models.ProductImage
+	if err := s.db.Find(&images).Error; err != nil {
+		return nil, err
+	}
+	for _, img := range images {
+		for _, key := range img.Keys() {
+			if key != "" {
+				references = append(references, imageReference{"product", img.ProductID, models.ObjectKey(key)})
+			}
+		}
+	}
+
+	var users []models.User
+	if err := s.db.Unscoped().Select("id", "avatar_key").Where("avatar_key <> ''").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		references = append(references, imageReference{"user", u.Id, models.ObjectKey(u.AvatarKey)})
+	}
+
+	return references, nil
+}
@@ -0,0 +1,434 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-admin/dto"
+	"go-admin/models"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// LoyaltyPointValue adalah nilai rupiah satu poin saat ditukar.
+	LoyaltyPointValue = 100.0
+	// LoyaltyExpiryDays adalah masa berlaku poin sejak didapat.
+	LoyaltyExpiryDays = 365
+	// LoyaltyExpiringSoonDays adalah batas poin yang dianggap segera kedaluwarsa.
+	LoyaltyExpiringSoonDays = 30
+)
+
+var (
+	ErrLoyaltyRuleNotFound   = errors.New("loyalty rule not found")
+	ErrInsufficientPoints    = errors.New("insufficient loyalty points")
+	ErrPointsWithoutCustomer = errors.New("points can only be used by a registered customer")
+)
+
+type LoyaltyService struct {
+	db *gorm.DB
+}
+
+func NewLoyaltyService(db *gorm.DB) *LoyaltyService {
+	return &LoyaltyService{db: db}
+}
+
+func (s *LoyaltyService) WithContext(ctx context.Context) *LoyaltyService {
+	return &LoyaltyService{db: s.db.WithContext(ctx)}
+}
+
+func (s *LoyaltyService) GetRules() ([]models.LoyaltyRule, error) {
+	rules := []models.LoyaltyRule{}
+	err := s.db.Preload("Product").Order("product_id IS NOT NULL, id").Find(&rules).Error
+	return rules, err
+}
+
+func (s *LoyaltyService) CreateRule(rule *models.LoyaltyRule) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := validateLoyaltyRule(tx, rule, 0); err != nil {
+			return err
+		}
+		return tx.Create(rule).Error
+	})
+}
+
+func (s *LoyaltyService) UpdateRule(id uint, data *models.LoyaltyRule) (*models.LoyaltyRule, error) {
+	var rule models.LoyaltyRule
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&rule, id).Error; err != nil {
+			return ErrLoyaltyRuleNotFound
+		}
+
+		rule.ProductID = data.ProductID
+		rule.SpendUnit = data.SpendUnit
+		rule.Points = data.Points
+		if err := validateLoyaltyRule(tx, &rule, id); err != nil {
+			return err
+		}
+		return tx.Save(&rule).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *LoyaltyService) DeleteRule(id uint) error {
+	var rule models.LoyaltyRule
+	if err := s.db.First(&rule, id).Error; err != nil {
+		return ErrLoyaltyRuleNotFound
+	}
+	return s.db.Delete(&rule).Error
+}
+
+// validateLoyaltyRule memastikan hanya ada satu aturan umum dan satu
+// aturan per produk.
+func validateLoyaltyRule(tx *gorm.DB, rule *models.LoyaltyRule, id uint) error {
+	if rule.Points < 0 {
+		return errors.New("points must not be negative")
+	}
+
+	existing := tx.Model(&models.LoyaltyRule{}).Where("id <> ?", id)
+	if rule.ProductID == nil {
+		if rule.SpendUnit <= 0 {
+			return errors.New("spend_unit must be greater than 0")
+		}
+		existing = existing.Where("product_id IS NULL")
+	} else {
+		var product models.Product
+		if err := tx.First(&product, *rule.ProductID).Error; err != nil {
+			return errors.New("product not found")
+		}
+		existing = existing.Where("product_id = ?", *rule.ProductID)
+	}
+
+	var count int64
+	if err := existing.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("loyalty rule already exists")
+	}
+	return nil
+}
+
+// Balance mengembalikan saldo poin customer tanpa menulis ke ledger. Poin
+// yang sudah lewat masa berlaku tetapi belum dicatat sebagai entry expire
+// (dicatat saat checkout berikutnya) dikurangkan langsung dari saldo.
+func (s *LoyaltyService) Balance(customerID uint) (*dto.LoyaltyBalance, error) {
+	if err := s.db.First(&models.Customer{}, customerID).Error; err != nil {
+		return nil, ErrCustomerNotFound
+	}
+
+	now := time.Now()
+	balance := dto.LoyaltyBalance{CustomerID: customerID, PointValue: LoyaltyPointValue}
+
+	points, err := pointsBalance(s.db, customerID)
+	if err != nil {
+		return nil, err
+	}
+	var expired int64
+	if err := s.db.Model(&models.LoyaltyEntry{}).
+		Select("COALESCE(SUM(remaining), 0)").
+		Where("customer_id = ? AND remaining > 0 AND expires_at <= ?", customerID, now).
+		Scan(&expired).Error; err != nil {
+		return nil, err
+	}
+	balance.Points = points - expired
+	balance.Value = float64(balance.Points) * LoyaltyPointValue
+
+	soon := now.AddDate(0, 0, LoyaltyExpiringSoonDays)
+	if err := s.db.Model(&models.LoyaltyEntry{}).
+		Select("COALESCE(SUM(remaining), 0)").
+		Where("customer_id = ? AND remaining > 0 AND expires_at > ? AND expires_at <= ?", customerID, now, soon).
+		Scan(&balance.ExpiringSoon).Error; err != nil {
+		return nil, err
+	}
+
+	var next models.LoyaltyEntry
+	err = s.db.Where("customer_id = ? AND remaining > 0 AND expires_at > ?", customerID, now).
+		Order("expires_at").
+		Take(&next).Error
+	if err == nil {
+		balance.NextExpiry = next.ExpiresAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return &balance, nil
+}
+
+func (s *LoyaltyService) History(customerID uint, query models.ListQuery) (fiber.Map, error) {
+	if err := s.db.First(&models.Customer{}, customerID).Error; err != nil {
+		return nil, ErrCustomerNotFound
+	}
+	return models.Paginate(s.db.Where("customer_id = ?", customerID), &models.LoyaltyEntry{}, query)
+}
+
+// calculatePoints menghitung poin dari isi cart. paidRatio adalah
+// perbandingan jumlah yang dibayar dengan total cart, supaya diskon dan
+// poin yang ditukar tidak ikut menghasilkan poin.
+func calculatePoints(tx *gorm.DB, carts []models.Cart, paidRatio float64) (int64, error) {
+	var rules []models.LoyaltyRule
+	if err := tx.Find(&rules).Error; err != nil {
+		return 0, err
+	}
+
+	var general *models.LoyaltyRule
+	productRules := make(map[uint]models.LoyaltyRule)
+	for i, rule := range rules {
+		if rule.ProductID == nil {
+			general = &rules[i]
+			continue
+		}
+		productRules[*rule.ProductID] = rule
+	}
+
+	var points, eligible float64
+	for _, cart := range carts {
+		if rule, ok := productRules[cart.ProductID]; ok {
+			points += rule.Points * cart.Qty
+			continue
+		}
+		eligible += cart.Price * cart.Qty * paidRatio
+	}
+	if general != nil && general.SpendUnit > 0 {
+		points += math.Floor(eligible/general.SpendUnit) * general.Points
+	}
+
+	return int64(math.Floor(points)), nil
+}
+
+func pointsBalance(tx *gorm.DB, customerID uint) (int64, error) {
+	var balance int64
+	err := tx.Model(&models.LoyaltyEntry{}).
+		Select("COALESCE(SUM(points), 0)").
+		Where("customer_id = ?", customerID).
+		Scan(&balance).Error
+	return balance, err
+}
+
+func pointsExpiry(now time.Time) *time.Time {
+	if LoyaltyExpiryDays <= 0 {
+		return nil
+	}
+	expiresAt := now.AddDate(0, 0, LoyaltyExpiryDays)
+	return &expiresAt
+}
+
+// expirePoints mencatat poin yang sudah lewat masa berlaku sebagai entry
+// expire, sehingga saldo di ledger selalu bisa dijumlahkan langsung.
+func expirePoints(tx *gorm.DB, customerID uint, now time.Time) error {
+	var entries []models.LoyaltyEntry
+	if err := tx.Where("customer_id = ? AND remaining > 0 AND expires_at <= ?", customerID, now).
+		Order("expires_at, id").
+		Find(&entries).Error; err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		expired := models.LoyaltyEntry{
+			CustomerID:    customerID,
+			SourceEntryID: &entry.ID,
+			Type:          models.LoyaltyExpire,
+			Points:        -entry.Remaining,
+			Note:          fmt.Sprintf("expired points from entry #%d", entry.ID),
+		}
+		if err := tx.Create(&expired).Error; err != nil {
+			return err
+		}
+		if err := useRemaining(tx, &entry, entry.Remaining); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockCustomer mengunci baris customer sampai transaksi database selesai,
+// supaya checkout dan void yang berjalan bersamaan untuk customer yang sama
+// tidak membaca saldo poin yang sama.
+func lockCustomer(tx *gorm.DB, customerID uint) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&models.Customer{}, customerID).Error
+}
+
+// redeemPoints memakai poin customer secara FIFO dari poin yang paling
+// cepat kedaluwarsa. Baris customer harus sudah dikunci dengan lockCustomer.
+func redeemPoints(tx *gorm.DB, customerID, transactionID uint, points int64) error {
+	balance, err := pointsBalance(tx, customerID)
+	if err != nil {
+		return err
+	}
+	if balance < points {
+		return fmt.Errorf("%w: balance %d, requested %d", ErrInsufficientPoints, balance, points)
+	}
+
+	var entries []models.LoyaltyEntry
+	if err := tx.Where("customer_id = ? AND remaining > 0", customerID).
+		Order("CASE WHEN expires_at IS NULL THEN 1 ELSE 0 END, expires_at, id").
+		Find(&entries).Error; err != nil {
+		return err
+	}
+
+	left := points
+	for _, entry := range entries {
+		if left == 0 {
+			break
+		}
+		used := entry.Remaining
+		if used > left {
+			used = left
+		}
+		if err := useRemaining(tx, &entry, used); err != nil {
+			return err
+		}
+		left -= used
+	}
+	if left > 0 {
+		return fmt.Errorf("%w: balance %d, requested %d", ErrInsufficientPoints, points-left, points)
+	}
+
+	redeem := models.LoyaltyEntry{
+		CustomerID:    customerID,
+		TransactionID: &transactionID,
+		Type:          models.LoyaltyRedeem,
+		Points:        -points,
+	}
+	return tx.Create(&redeem).Error
+}
+
+// useRemaining mengurangi sisa poin entry di database, bukan dari nilai
+// yang sudah dibaca, dan gagal jika sisanya sudah berubah.
+func useRemaining(tx *gorm.DB, entry *models.LoyaltyEntry, used int64) error {
+	result := tx.Model(entry).
+		Where("remaining >= ?", used).
+		Update("remaining", gorm.Expr("remaining - ?", used))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientPoints
+	}
+	return nil
+}
+
+func earnPoints(tx *gorm.DB, customerID, transactionID uint, points int64, now time.Time) error {
+	earn := models.LoyaltyEntry{
+		CustomerID:    customerID,
+		TransactionID: &transactionID,
+		Type:          models.LoyaltyEarn,
+		Points:        points,
+		ExpiresAt:     pointsExpiry(now),
+	}
+	return creditPoints(tx, &earn)
+}
+
+// creditPoints mencatat entry yang menambah poin. Poin baru lebih dulu
+// melunasi utang poin (entry reverse dengan Remaining negatif), sisanya
+// menjadi Remaining entry tersebut.
+func creditPoints(tx *gorm.DB, entry *models.LoyaltyEntry) error {
+	var debts []models.LoyaltyEntry
+	if err := tx.Where("customer_id = ? AND remaining < 0", entry.CustomerID).
+		Order("id").
+		Find(&debts).Error; err != nil {
+		return err
+	}
+
+	left := entry.Points
+	for _, debt := range debts {
+		if left == 0 {
+			break
+		}
+		paid := -debt.Remaining
+		if paid > left {
+			paid = left
+		}
+		if err := tx.Model(&debt).Update("remaining", gorm.Expr("remaining + ?", paid)).Error; err != nil {
+			return err
+		}
+		left -= paid
+	}
+
+	entry.Remaining = left
+	return tx.Create(entry).Error
+}
+
+// reverseTransactionPoints membatalkan poin dari transaksi yang di-void:
+// poin yang ditukar dikembalikan dan sisa poin yang didapat ditarik kembali.
+// Bagian poin yang didapat tetapi sudah terpakai dicatat sebagai utang poin
+// (Remaining negatif) yang dilunasi dari poin berikutnya, sehingga tidak
+// ikut hilang saat poin berikutnya kedaluwarsa. Poin yang sudah kedaluwarsa
+// tidak dihitung sebagai terpakai.
+func reverseTransactionPoints(tx *gorm.DB, transactionID uint, now time.Time) error {
+	var entries []models.LoyaltyEntry
+	if err := tx.Where("transaction_id = ? AND type IN ?", transactionID,
+		[]string{models.LoyaltyEarn, models.LoyaltyRedeem}).
+		Order("id").
+		Find(&entries).Error; err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Type == models.LoyaltyRedeem {
+			refund := models.LoyaltyEntry{
+				CustomerID:    entry.CustomerID,
+				TransactionID: &transactionID,
+				Type:          models.LoyaltyReverse,
+				SourceEntryID: &entry.ID,
+				Points:        -entry.Points,
+				ExpiresAt:     pointsExpiry(now),
+				Note:          fmt.Sprintf("void of %s entry #%d", entry.Type, entry.ID),
+			}
+			if err := creditPoints(tx, &refund); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if entry.Remaining > 0 {
+			if err := useRemaining(tx, &entry, entry.Remaining); err != nil {
+				return err
+			}
+			reverse := models.LoyaltyEntry{
+				CustomerID:    entry.CustomerID,
+				TransactionID: &transactionID,
+				SourceEntryID: &entry.ID,
+				Type:          models.LoyaltyReverse,
+				Points:        -entry.Remaining,
+				Note:          fmt.Sprintf("void of %s entry #%d", entry.Type, entry.ID),
+			}
+			if err := tx.Create(&reverse).Error; err != nil {
+				return err
+			}
+		}
+
+		var expired int64
+		if err := tx.Model(&models.LoyaltyEntry{}).
+			Select("COALESCE(SUM(-points), 0)").
+			Where("source_entry_id = ? AND type = ?", entry.ID, models.LoyaltyExpire).
+			Scan(&expired).Error; err != nil {
+			return err
+		}
+
+		if consumed := entry.Points - entry.Remaining - expired; consumed > 0 {
+			debt := models.LoyaltyEntry{
+				CustomerID:    entry.CustomerID,
+				TransactionID: &transactionID,
+				SourceEntryID: &entry.ID,
+				Type:          models.LoyaltyReverse,
+				Points:        -consumed,
+				Remaining:     -consumed,
+				Note:          fmt.Sprintf("void of used points from %s entry #%d", entry.Type, entry.ID),
+			}
+			if err := tx.Create(&debt).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
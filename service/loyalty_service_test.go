@@ -0,0 +1,164 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"go-admin/database"
+	"go-admin/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return db
+}
+
+func expectPoints(t *testing.T, db *gorm.DB, customerID uint, step string, want int64) {
+	t.Helper()
+
+	balance, err := pointsBalance(db, customerID)
+	if err != nil {
+		t.Fatalf("%s: balance: %v", step, err)
+	}
+	var remaining int64
+	db.Model(&models.LoyaltyEntry{}).
+		Select("COALESCE(SUM(remaining), 0)").
+		Where("customer_id = ?", customerID).
+		Scan(&remaining)
+
+	if balance != want {
+		t.Errorf("%s: balance = %d, want %d", step, balance, want)
+	}
+	if remaining != balance {
+		t.Errorf("%s: remaining = %d does not match balance %d", step, remaining, balance)
+	}
+}
+
+func TestVoidOfUsedPointsIsPaidByLaterEarn(t *testing.T) {
+	db := openTestDB(t)
+	customer := models.Customer{Name: "Budi"}
+	db.Create(&customer)
+	now := time.Now()
+
+	if err := earnPoints(db, customer.Id, 1, 10, now); err != nil {
+		t.Fatalf("earn: %v", err)
+	}
+	if err := redeemPoints(db, customer.Id, 2, 4); err != nil {
+		t.Fatalf("redeem: %v", err)
+	}
+	expectPoints(t, db, customer.Id, "after redeem", 6)
+
+	// Transaksi pertama di-void setelah 4 poinnya terpakai
+	if err := reverseTransactionPoints(db, 1, now); err != nil {
+		t.Fatalf("void: %v", err)
+	}
+	expectPoints(t, db, customer.Id, "after void", -4)
+	if err := redeemPoints(db, customer.Id, 3, 1); err == nil {
+		t.Error("redeem with negative balance should fail")
+	}
+
+	if err := earnPoints(db, customer.Id, 4, 10, now); err != nil {
+		t.Fatalf("earn again: %v", err)
+	}
+	expectPoints(t, db, customer.Id, "after earning again", 6)
+
+	if err := expirePoints(db, customer.Id, now.AddDate(0, 0, LoyaltyExpiryDays+1)); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+	expectPoints(t, db, customer.Id, "after expiry", 0)
+}
+
+func TestVoidRefundsRedeemedPoints(t *testing.T) {
+	db := openTestDB(t)
+	customer := models.Customer{Name: "Siti"}
+	db.Create(&customer)
+	now := time.Now()
+
+	if err := earnPoints(db, customer.Id, 1, 10, now); err != nil {
+		t.Fatalf("earn: %v", err)
+	}
+	if err := redeemPoints(db, customer.Id, 2, 10); err != nil {
+		t.Fatalf("redeem: %v", err)
+	}
+	if err := earnPoints(db, customer.Id, 2, 3, now); err != nil {
+		t.Fatalf("earn on redeem transaction: %v", err)
+	}
+
+	if err := reverseTransactionPoints(db, 2, now); err != nil {
+		t.Fatalf("void: %v", err)
+	}
+	expectPoints(t, db, customer.Id, "after void", 10)
+}
+
+func TestVoidOfExpiredPointsCreatesNoDebt(t *testing.T) {
+	db := openTestDB(t)
+	customer := models.Customer{Name: "Dewi"}
+	db.Create(&customer)
+	now := time.Now()
+
+	if err := earnPoints(db, customer.Id, 1, 10, now); err != nil {
+		t.Fatalf("earn: %v", err)
+	}
+	later := now.AddDate(0, 0, LoyaltyExpiryDays+1)
+	if err := expirePoints(db, customer.Id, later); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+
+	if err := reverseTransactionPoints(db, 1, later); err != nil {
+		t.Fatalf("void: %v", err)
+	}
+	expectPoints(t, db, customer.Id, "after void", 0)
+
+	var debts int64
+	db.Model(&models.LoyaltyEntry{}).Where("customer_id = ? AND remaining < 0", customer.Id).Count(&debts)
+	if debts != 0 {
+		t.Errorf("void of expired points created %d debt entries", debts)
+	}
+}
+
+func TestBalanceDoesNotWriteExpiry(t *testing.T) {
+	db := openTestDB(t)
+	customer := models.Customer{Name: "Andi"}
+	db.Create(&customer)
+	now := time.Now()
+
+	if err := earnPoints(db, customer.Id, 1, 10, now); err != nil {
+		t.Fatalf("earn: %v", err)
+	}
+	expired := now.Add(-time.Hour)
+	db.Create(&models.LoyaltyEntry{CustomerID: customer.Id, Type: models.LoyaltyEarn, Points: 5, Remaining: 5, ExpiresAt: &expired})
+
+	balance, err := NewLoyaltyService(db).Balance(customer.Id)
+	if err != nil {
+		t.Fatalf("balance: %v", err)
+	}
+	if balance.Points != 10 {
+		t.Errorf("points = %d, want 10", balance.Points)
+	}
+	if balance.NextExpiry == nil || !balance.NextExpiry.After(now) {
+		t.Errorf("next expiry = %v, want a future date", balance.NextExpiry)
+	}
+
+	var entries int64
+	db.Model(&models.LoyaltyEntry{}).Where("type = ?", models.LoyaltyExpire).Count(&entries)
+	if entries != 0 {
+		t.Errorf("balance wrote %d expire entries", entries)
+	}
+}
@@ -3,12 +3,10 @@ package service
 import (
 	"context"
 	"fmt"
+	"go-admin/models"
 	"io"
-	"net/url"
-	"path"
-	"strings"
-	"time"
 
+	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
@@ -40,15 +38,12 @@ func NewMinioService(endpoint, accessKey, secretKey, bucketName string, usessl b
 func (m *MinioService) UploadFile(file io.Reader, fileSize int64, contentType string) (string, error) {
 	ctx := context.Background()
 
-	// Generate unique filename
-	objectName := fmt.Sprintf("%d", time.Now().UnixNano())
-	if strings.Contains(contentType, "image/jpeg") {
-		objectName += ".jpg"
-	} else if strings.Contains(contentType, "image/png") {
-		objectName += ".png"
-	} else {
-		objectName += ".bin"
+	// Nama file unik dari UUID; hanya gambar hasil encode ulang yang disimpan
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return "", ErrUnsupportedImage
 	}
+	objectName := uuid.NewString() + ext
 
 	// Create bucket if not exists
 	exists, err := m.client.BucketExists(ctx, m.bucketName)
@@ -72,28 +67,29 @@ func (m *MinioService) UploadFile(file io.Reader, fileSize int64, contentType st
 		return "", err
 	}
 
+	// Yang disimpan di database hanya key; url dibuat dengan models.ObjectURL
+	return objectName, nil
+}
+
+// PublicBaseURL adalah url bucket langsung ke MinIO, dipakai sebagai
+// models.StorageBaseURL jika tidak ada url publik lain (misalnya CDN).
+func (m *MinioService) PublicBaseURL() string {
 	protocol := "http"
 	if m.usessl {
 		protocol = "https"
 	}
-	return fmt.Sprintf("%s://%s/%s/%s", protocol, m.endpoint, m.bucketName, objectName), nil
+	return fmt.Sprintf("%s://%s/%s", protocol, m.endpoint, m.bucketName)
 }
 
-func (m *MinioService) GetFile(imgUrl string) ([]byte, error) {
+// GetFile menerima key object. Url lengkap dari data lama tetap diterima.
+func (m *MinioService) GetFile(key string) ([]byte, error) {
 	ctx := context.Background()
 
-	// Parse URL to get object name
-	u, err := url.Parse(imgUrl)
-	if err != nil {
-		return nil, err
-	}
-	objectName := path.Base(u.Path)
-
 	// Get object
 	obj, err := m.client.GetObject(
 		ctx,
 		m.bucketName,
-		objectName,
+		models.ObjectKey(key),
 		minio.GetObjectOptions{},
 	)
 	if err != nil {
@@ -104,15 +100,47 @@ func (m *MinioService) GetFile(imgUrl string) ([]byte, error) {
 	return io.ReadAll(obj)
 }
 
-func (m *MinioService) DeleteFile(imgUrl string) error {
+func (m *MinioService) DeleteFile(key string) error {
 	ctx := context.Background()
 
-	// Parse URL to get object name
-	u, err := url.Parse(imgUrl)
+	return m.client.RemoveObject(ctx, m.bucketName, models.ObjectKey(key), minio.RemoveObjectOptions{})
+}
+
+// OpenFile membuka object untuk di-stream. Info berisi ETag, ukuran dan
+// content type object.
+func (m *MinioService) OpenFile(key string) (*minio.Object, minio.ObjectInfo, error) {
+	ctx := context.Background()
+
+	obj, err := m.client.GetObject(ctx, m.bucketName, models.ObjectKey(key), minio.GetObjectOptions{})
 	if err != nil {
-		return err
+		return nil, minio.ObjectInfo{}, err
 	}
-	objectName := path.Base(u.Path)
 
-	return m.client.RemoveObject(ctx, m.bucketName, objectName, minio.RemoveObjectOptions{})
-}
\ No newline at end of file
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, minio.ObjectInfo{}, err
+	}
+	return obj, info, nil
+}
+
+// IsNotFound mengecek apakah error dari MinIO berarti object tidak ada.
+func IsNotFound(err error) bool {
+	code := minio.ToErrorResponse(err).Code
+	return code == "NoSuchKey" || code == "NoSuchBucket"
+}
+
+// ListFiles mengembalikan semua object di bucket.
+func (m *MinioService) ListFiles(ctx context.Context) ([]minio.ObjectInfo, error) {
+	var objects []minio.ObjectInfo
+	for obj := range m.client.ListObjects(ctx, m.bucketName, minio.ListObjectsOptions{Recursive: true}) {
+		if obj.Err != nil {
+			if IsNotFound(obj.Err) {
+				return nil, nil
+			}
+			return nil, obj.Err
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
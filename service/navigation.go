@@ -0,0 +1,43 @@
+package service
+
+import "go-admin/dto"
+
+// navigation adalah menu aplikasi. Item dengan Permission hanya ditampilkan
+// untuk user yang memiliki permission tersebut; grup tanpa anak yang
+// tersisa akan disembunyikan.
+var navigation = []dto.NavItem{
+	{Key: "dashboard", Title: "Dashboard", Path: "/dashboard", Icon: "home", Permission: "view_dashboard"},
+	{Key: "pos", Title: "Transaksi", Path: "/transactions", Icon: "shopping-cart", Permission: "view_transactions"},
+	{Key: "master", Title: "Master Data", Icon: "database", Children: []dto.NavItem{
+		{Key: "products", Title: "Produk", Path: "/products", Permission: "view_products"},
+		{Key: "customers", Title: "Customer", Path: "/customers", Permission: "view_customers"},
+		{Key: "loyalty", Title: "Loyalty", Path: "/loyalty", Permission: "edit_loyalty"},
+	}},
+	{Key: "reports", Title: "Laporan", Icon: "bar-chart", Children: []dto.NavItem{
+		{Key: "sales", Title: "Penjualan", Path: "/sales", Permission: "view_sales"},
+		{Key: "profit", Title: "Profit", Path: "/profits", Permission: "view_profit"},
+		{Key: "receivables", Title: "Piutang", Path: "/receivables", Permission: "view_receivables"},
+	}},
+	{Key: "settings", Title: "Pengaturan", Icon: "settings", Children: []dto.NavItem{
+		{Key: "users", Title: "User", Path: "/users", Permission: "view_users"},
+		{Key: "roles", Title: "Role", Path: "/roles", Permission: "view_roles"},
+		{Key: "audit", Title: "Audit Log", Path: "/audit", Permission: "view_audit"},
+	}},
+}
+
+func filterNavigation(items []dto.NavItem, allowed map[string]bool) []dto.NavItem {
+	result := []dto.NavItem{}
+	for _, item := range items {
+		if item.Permission != "" && !allowed[item.Permission] {
+			continue
+		}
+		if len(item.Children) > 0 {
+			item.Children = filterNavigation(item.Children, allowed)
+			if len(item.Children) == 0 {
+				continue
+			}
+		}
+		result = append(result, item)
+	}
+	return result
+}
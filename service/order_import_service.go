@@ -0,0 +1,428 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go-admin/dto"
+	"go-admin/models"
+	"io"
+	"mime/multipart"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var errImportDryRun = errors.New("dry run")
+
+var orderImportColumns = []string{"id", "name", "email", "product title", "price", "quantity"}
+
+var orderImportDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+type orderImportItem struct {
+	row   *dto.OrderImportRow
+	name  string
+	price float64
+	qty   float64
+	date  time.Time
+}
+
+type orderImportGroup struct {
+	id    string
+	items []*orderImportItem
+}
+
+// orderImportCache menyimpan customer dan produk yang sudah dicocokkan atau
+// dibuat selama import, dengan status "created" atau "matched".
+type orderImportCache struct {
+	customers map[string]*models.Customer
+	products  map[string]*models.Product
+	status    map[string]string
+}
+
+// ImportOrders mengimpor order lama dari file CSV/XLSX dengan format
+// csv/orders.csv (ID,Name,Email,Product Title,Price,Quantity).
+func (s *TransactionService) ImportOrders(file *multipart.FileHeader, options dto.OrderImportOptions) (*dto.OrderImportResult, error) {
+	rows, err := readSpreadsheet(file)
+	if err != nil {
+		return nil, err
+	}
+	return s.importOrderRows(rows, options)
+}
+
+// ImportOrdersFrom sama dengan ImportOrders untuk file yang dibaca dari disk (CLI).
+func (s *TransactionService) ImportOrdersFrom(name string, r io.Reader, options dto.OrderImportOptions) (*dto.OrderImportResult, error) {
+	rows, err := parseSpreadsheet(name, r)
+	if err != nil {
+		return nil, err
+	}
+	return s.importOrderRows(rows, options)
+}
+
+// importOrderRows membuat satu transaksi per ID order dengan tanggal dari
+// kolom date (opsional) atau options.Date. Customer dicocokkan lewat email
+// dan produk lewat judul; yang belum ada akan dibuat. Order yang punya baris
+// tidak valid dilewati seluruhnya, order yang sudah pernah diimpor
+// (external_id sama) juga dilewati. Stok hanya dikurangi jika UpdateStock.
+func (s *TransactionService) importOrderRows(rows [][]string, options dto.OrderImportOptions) (*dto.OrderImportResult, error) {
+	if len(rows) < 2 {
+		return nil, errors.New("file has no data rows")
+	}
+
+	index := headerIndex(rows[0])
+	for _, column := range orderImportColumns {
+		if _, ok := index[column]; !ok {
+			return nil, errors.New("missing column: " + column)
+		}
+	}
+	_, hasDate := index["date"]
+
+	var defaultDate *time.Time
+	if options.Date != "" {
+		date, err := parseImportDate(options.Date)
+		if err != nil {
+			return nil, err
+		}
+		defaultDate = &date
+	}
+	if !hasDate && defaultDate == nil {
+		return nil, errors.New("date is required when the file has no date column")
+	}
+
+	result := &dto.OrderImportResult{DryRun: options.DryRun}
+	result.Rows = make([]dto.OrderImportRow, 0, len(rows)-1)
+	items := make([]*orderImportItem, 0, len(rows)-1)
+
+	for i, row := range rows[1:] {
+		item := &orderImportItem{name: cell(row, index, "name")}
+		r := dto.OrderImportRow{
+			Row:          i + 2,
+			OrderID:      cell(row, index, "id"),
+			Email:        strings.ToLower(cell(row, index, "email")),
+			ProductTitle: cell(row, index, "product title"),
+		}
+		price := cell(row, index, "price")
+		quantity := cell(row, index, "quantity")
+		if r.OrderID == "" && r.Email == "" && r.ProductTitle == "" && price == "" && quantity == "" {
+			continue
+		}
+
+		if r.OrderID == "" {
+			r.Errors = append(r.Errors, "id is required")
+		}
+
+		if r.Email == "" {
+			r.Errors = append(r.Errors, "email is required")
+		} else if _, err := mail.ParseAddress(r.Email); err != nil {
+			r.Errors = append(r.Errors, "email is invalid")
+		}
+
+		if r.ProductTitle == "" {
+			r.Errors = append(r.Errors, "product title is required")
+		}
+
+		if n, err := strconv.ParseFloat(price, 64); err != nil || n < 0 {
+			r.Errors = append(r.Errors, "price is invalid")
+		} else {
+			item.price = n
+		}
+
+		if n, err := strconv.ParseFloat(quantity, 64); err != nil || n <= 0 {
+			r.Errors = append(r.Errors, "quantity is invalid")
+		} else {
+			item.qty = n
+		}
+
+		if value := cell(row, index, "date"); value != "" {
+			date, err := parseImportDate(value)
+			if err != nil {
+				r.Errors = append(r.Errors, err.Error())
+			} else {
+				item.date = date
+			}
+		} else if defaultDate != nil {
+			item.date = *defaultDate
+		} else {
+			r.Errors = append(r.Errors, "date is required")
+		}
+
+		result.Rows = append(result.Rows, r)
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, errors.New("file has no data rows")
+	}
+	for i := range items {
+		items[i].row = &result.Rows[i]
+	}
+
+	groups := groupImportOrders(items)
+	result.Orders = len(groups)
+
+	cache := &orderImportCache{
+		customers: make(map[string]*models.Customer),
+		products:  make(map[string]*models.Product),
+		status:    make(map[string]string),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+
+		for _, group := range groups {
+			if !group.valid() {
+				continue
+			}
+
+			var existing models.Transaction
+			err := tx.Where("external_id = ?", group.id).Take(&existing).Error
+			if err == nil {
+				result.OrdersSkipped++
+				for _, item := range group.items {
+					item.row.TransactionID = existing.ID
+				}
+				continue
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			if err := s.importOrder(tx, group, options, cache); err != nil {
+				return err
+			}
+			result.OrdersCreated++
+		}
+
+		if options.DryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportDryRun) {
+		return nil, err
+	}
+
+	for key, status := range cache.status {
+		switch {
+		case strings.HasPrefix(key, "customer:") && status == "created":
+			result.CustomersCreated++
+		case strings.HasPrefix(key, "customer:"):
+			result.CustomersMatched++
+		case status == "created":
+			result.ProductsCreated++
+		default:
+			result.ProductsMatched++
+		}
+	}
+
+	for _, r := range result.Rows {
+		if len(r.Errors) > 0 {
+			result.Failed++
+		} else if r.Customer != "" {
+			result.Created++
+		}
+	}
+	if options.DryRun {
+		for i := range result.Rows {
+			if result.Rows[i].Customer != "" {
+				result.Rows[i].TransactionID = 0
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (s *TransactionService) importOrder(tx *gorm.DB, group *orderImportGroup, options dto.OrderImportOptions, cache *orderImportCache) error {
+	first := group.items[0]
+
+	customer, customerStatus, err := cache.customer(tx, first.row.Email, first.name)
+	if err != nil {
+		return err
+	}
+
+	var grandTotal float64
+	for _, item := range group.items {
+		grandTotal += item.price * item.qty
+	}
+
+	transaction := models.Transaction{
+		UserID:     options.UserID,
+		CustomerID: customer.Id,
+		ExternalID: group.id,
+		Cash:       grandTotal,
+		GrandTotal: grandTotal,
+		CreatedAt:  first.date,
+		UpdatedAt:  first.date,
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		return err
+	}
+
+	for _, item := range group.items {
+		product, productStatus, err := cache.product(tx, item.row.ProductTitle, item.price)
+		if err != nil {
+			return err
+		}
+		item.row.Customer = customerStatus
+		item.row.Product = productStatus
+
+		detail := models.TransactionDetail{
+			TransactionID: transaction.ID,
+			ProductID:     product.ID,
+			Qty:           item.qty,
+			Price:         item.price,
+		}
+		if err := tx.Create(&detail).Error; err != nil {
+			return err
+		}
+
+		profit := models.Profit{
+			TransactionId: transaction.ID,
+			Total:         (item.price - product.Price) * item.qty,
+			CreatedAt:     first.date,
+			UpdatedAt:     first.date,
+		}
+		if err := tx.Create(&profit).Error; err != nil {
+			return err
+		}
+
+		if options.UpdateStock {
+			if err := tx.Model(&models.Product{}).
+				Where("id = ?", product.ID).
+				Update("stock", gorm.Expr("stock - ?", item.qty)).
+				Error; err != nil {
+				return err
+			}
+		}
+
+		item.row.TransactionID = transaction.ID
+	}
+
+	return nil
+}
+
+// groupImportOrders mengelompokkan baris per ID order sesuai urutan di file.
+// Semua baris dalam satu order harus memakai email dan tanggal yang sama.
+func groupImportOrders(items []*orderImportItem) []*orderImportGroup {
+	var groups []*orderImportGroup
+	byID := make(map[string]*orderImportGroup)
+
+	for _, item := range items {
+		if item.row.OrderID == "" {
+			continue
+		}
+		group, ok := byID[item.row.OrderID]
+		if !ok {
+			group = &orderImportGroup{id: item.row.OrderID}
+			byID[group.id] = group
+			groups = append(groups, group)
+		}
+		group.items = append(group.items, item)
+	}
+
+	for _, group := range groups {
+		first := group.items[0]
+		for _, item := range group.items[1:] {
+			if item.row.Email != first.row.Email {
+				item.row.Errors = append(item.row.Errors, "email differs from row "+strconv.Itoa(first.row.Row))
+			}
+			if !item.date.Equal(first.date) {
+				item.row.Errors = append(item.row.Errors, "date differs from row "+strconv.Itoa(first.row.Row))
+			}
+		}
+
+		if !group.valid() {
+			for _, item := range group.items {
+				if len(item.row.Errors) == 0 {
+					item.row.Errors = append(item.row.Errors, fmt.Sprintf("order %s has invalid rows", group.id))
+				}
+			}
+		}
+	}
+
+	return groups
+}
+
+func (g *orderImportGroup) valid() bool {
+	for _, item := range g.items {
+		if len(item.row.Errors) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *orderImportCache) customer(tx *gorm.DB, email, name string) (*models.Customer, string, error) {
+	key := "customer:" + email
+	if customer, ok := c.customers[email]; ok {
+		return customer, c.status[key], nil
+	}
+
+	var customer models.Customer
+	err := tx.Where("LOWER(email) = ?", email).Take(&customer).Error
+	if err == nil {
+		// Lengkapi nama yang masih kosong, data yang sudah ada tidak ditimpa
+		if customer.Name == "" && name != "" {
+			if err := tx.Model(&customer).Update("name", name).Error; err != nil {
+				return nil, "", err
+			}
+		}
+		c.customers[email] = &customer
+		c.status[key] = "matched"
+		return &customer, "matched", nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", err
+	}
+
+	customer = models.Customer{Email: &email, Name: name}
+	normalizeCustomer(&customer)
+	if err := tx.Create(&customer).Error; err != nil {
+		return nil, "", err
+	}
+	c.customers[email] = &customer
+	c.status[key] = "created"
+	return &customer, "created", nil
+}
+
+func (c *orderImportCache) product(tx *gorm.DB, title string, price float64) (*models.Product, string, error) {
+	name := strings.ToLower(title)
+	key := "product:" + name
+	if product, ok := c.products[name]; ok {
+		return product, c.status[key], nil
+	}
+
+	var product models.Product
+	err := tx.Where("LOWER(title) = ?", name).Order("id").Take(&product).Error
+	if err == nil {
+		c.products[name] = &product
+		c.status[key] = "matched"
+		return &product, "matched", nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", err
+	}
+
+	// Harga beli tidak ada di file lama, produk baru dibuat tanpa stok
+	product = models.Product{
+		Barcode:   generateBarcode(),
+		Title:     title,
+		SellPrice: price,
+	}
+	if err := tx.Create(&product).Error; err != nil {
+		return nil, "", err
+	}
+	c.products[name] = &product
+	c.status[key] = "created"
+	return &product, "created", nil
+}
+
+func parseImportDate(value string) (time.Time, error) {
+	for _, layout := range orderImportDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, errors.New("date is invalid: " + value)
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-admin/models"
+	"mime/multipart"
+
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+)
+
+const (
+	// ThumbnailSize adalah sisi thumbnail persegi untuk daftar produk.
+	ThumbnailSize = 200
+	// MediumSize adalah sisi terpanjang gambar ukuran sedang untuk detail produk.
+	MediumSize = 800
+)
+
+var (
+	ErrProductImageNotFound = errors.New("product image not found")
+)
+
+func (s *ProductService) GetImages(productID uint) ([]models.ProductImage, error) {
+	if err := s.db.First(&models.Product{}, productID).Error; err != nil {
+		return nil, ErrProductNotFound
+	}
+	images := []models.ProductImage{}
+	err := s.db.Scopes(models.OrderedImages).Where("product_id = ?", productID).Find(&images).Error
+	return images, err
+}
+
+// AddImages menambah gambar ke akhir galeri. Gambar pertama menjadi gambar
+// utama jika produk belum punya gambar.
+func (s *ProductService) AddImages(productID uint, files []*multipart.FileHeader) ([]models.ProductImage, error) {
+	if err := s.db.First(&models.Product{}, productID).Error; err != nil {
+		return nil, ErrProductNotFound
+	}
+
+	images, err := s.uploadImages(files)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var position int
+		if err := tx.Model(&models.ProductImage{}).
+			Select("COALESCE(MAX(position) + 1, 0)").
+			Where("product_id = ?", productID).
+			Scan(&position).Error; err != nil {
+			return err
+		}
+
+		for i := range images {
+			images[i].ProductID = productID
+			images[i].Position = position + i
+			if err := tx.Create(&images[i]).Error; err != nil {
+				return err
+			}
+		}
+		return syncPrimaryImage(tx, productID)
+	})
+	if err != nil {
+		s.deleteImageFiles(images...)
+		return nil, err
+	}
+
+	return s.GetImages(productID)
+}
+
+func (s *ProductService) SetPrimaryImage(productID, imageID uint) ([]models.ProductImage, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var img models.ProductImage
+		if err := tx.Where("product_id = ?", productID).First(&img, imageID).Error; err != nil {
+			return ErrProductImageNotFound
+		}
+
+		var images []models.ProductImage
+		if err := tx.Where("product_id = ? AND is_primary = ? AND id <> ?", productID, true, img.ID).Find(&images).Error; err != nil {
+			return err
+		}
+		for i := range images {
+			if err := tx.Model(&images[i]).Update("is_primary", false).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&img).Update("is_primary", true).Error; err != nil {
+			return err
+		}
+		return syncPrimaryImage(tx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetImages(productID)
+}
+
+// ReorderImages mengatur ulang urutan galeri. ids harus berisi semua gambar
+// produk tepat satu kali.
+func (s *ProductService) ReorderImages(productID uint, ids []uint) ([]models.ProductImage, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Product{}, productID).Error; err != nil {
+			return ErrProductNotFound
+		}
+
+		var images []models.ProductImage
+		if err := tx.Where("product_id = ?", productID).Find(&images).Error; err != nil {
+			return err
+		}
+		if len(ids) != len(images) {
+			return errors.New("ids must contain every image of the product")
+		}
+
+		byID := make(map[uint]*models.ProductImage, len(images))
+		for i := range images {
+			byID[images[i].ID] = &images[i]
+		}
+		for position, id := range ids {
+			img, ok := byID[id]
+			if !ok {
+				return fmt.Errorf("image %d does not belong to the product", id)
+			}
+			delete(byID, id)
+			if img.Position == position {
+				continue
+			}
+			if err := tx.Model(img).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetImages(productID)
+}
+
+func (s *ProductService) DeleteImage(productID, imageID uint) error {
+	var img models.ProductImage
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).First(&img, imageID).Error; err != nil {
+			return ErrProductImageNotFound
+		}
+		if err := tx.Delete(&img).Error; err != nil {
+			return err
+		}
+		return syncPrimaryImage(tx, productID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.deleteImageFiles(img)
+	return nil
+}
+
+// uploadImages mengunggah file asli beserta versi medium dan thumbnail.
+// Jika salah satu gagal, file yang sudah terunggah dihapus lagi.
+func (s *ProductService) uploadImages(files []*multipart.FileHeader) ([]models.ProductImage, error) {
+	images := make([]models.ProductImage, 0, len(files))
+	for _, file := range files {
+		img, err := s.uploadImage(file)
+		if err != nil {
+			s.deleteImageFiles(images...)
+			return nil, err
+		}
+		images = append(images, *img)
+	}
+	return images, nil
+}
+
+func (s *ProductService) uploadImage(file *multipart.FileHeader) (*models.ProductImage, error) {
+	fileSrc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer fileSrc.Close()
+
+	src, contentType, err := decodeUpload(fileSrc)
+	if err != nil {
+		return nil, err
+	}
+	original, contentType, err := sanitizeImage(src, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	medium, err := encodeJPEG(fitImage(src, MediumSize))
+	if err != nil {
+		return nil, err
+	}
+	thumbnail, err := encodeJPEG(cropSquare(src, ThumbnailSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var img models.ProductImage
+	if img.OriginalKey, err = s.minioClient.UploadFile(bytes.NewReader(original), int64(len(original)), contentType); err != nil {
+		return nil, err
+	}
+	if img.MediumKey, err = s.minioClient.UploadFile(bytes.NewReader(medium), int64(len(medium)), "image/jpeg"); err != nil {
+		s.deleteImageFiles(img)
+		return nil, err
+	}
+	if img.ThumbnailKey, err = s.minioClient.UploadFile(bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg"); err != nil {
+		s.deleteImageFiles(img)
+		return nil, err
+	}
+	return &img, nil
+}
+
+func (s *ProductService) deleteImageFiles(images ...models.ProductImage) {
+	for _, img := range images {
+		for _, key := range img.Keys() {
+			if key == "" {
+				continue
+			}
+			if err := s.minioClient.DeleteFile(key); err != nil {
+				fmt.Printf("Warning: failed to delete image: %v\n", err)
+			}
+		}
+	}
+}
+
+// syncPrimaryImage memastikan produk yang punya gambar selalu punya satu
+// gambar utama, dan menyalin key-nya ke Product.ImageKey untuk kompatibilitas.
+func syncPrimaryImage(tx *gorm.DB, productID uint) error {
+	var images []models.ProductImage
+	if err := tx.Scopes(models.OrderedImages).Where("product_id = ?", productID).Find(&images).Error; err != nil {
+		return err
+	}
+
+	var primary *models.ProductImage
+	for i := range images {
+		if images[i].IsPrimary {
+			primary = &images[i]
+			break
+		}
+	}
+	if primary == nil && len(images) > 0 {
+		primary = &images[0]
+		if err := tx.Model(primary).Update("is_primary", true).Error; err != nil {
+			return err
+		}
+	}
+
+	imageKey := ""
+	if primary != nil {
+		imageKey = primary.OriginalKey
+	}
+	return tx.Model(&models.Product{}).Where("id = ? AND image_key <> ?", productID, imageKey).Update("image_key", imageKey).Error
+}
+
+// replacePrimaryImage mengganti gambar utama produk dengan file baru di
+// posisi yang sama. Produk tanpa gambar mendapat gambar utama baru.
+func (s *ProductService) replacePrimaryImage(productID uint, file *multipart.FileHeader) error {
+	img, err := s.uploadImage(file)
+	if err != nil {
+		return err
+	}
+
+	var old *models.ProductImage
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var primary models.ProductImage
+		err := tx.Where("product_id = ? AND is_primary = ?", productID, true).First(&primary).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			old = &primary
+			img.Position = primary.Position
+			if err := tx.Delete(&primary).Error; err != nil {
+				return err
+			}
+		}
+
+		img.ProductID = productID
+		img.IsPrimary = true
+		if err := tx.Create(img).Error; err != nil {
+			return err
+		}
+		return syncPrimaryImage(tx, productID)
+	})
+	if err != nil {
+		s.deleteImageFiles(*img)
+		return err
+	}
+
+	if old != nil {
+		s.deleteImageFiles(*old)
+	}
+	return nil
+}
+
+// ImageKey mengembalikan key file gambar produk sesuai ukuran (thumbnail,
+// medium, atau original). imageID 0 berarti gambar utama.
+func (s *ProductService) ImageKey(productID, imageID uint, size string) (string, error) {
+	var product models.Product
+	if err := s.db.First(&product, productID).Error; err != nil {
+		return "", ErrProductNotFound
+	}
+
+	img := models.ProductImage{OriginalKey: product.ImageKey, MediumKey: product.ImageKey, ThumbnailKey: product.ImageKey}
+	query := s.db.Where("product_id = ?", productID)
+	if imageID != 0 {
+		query = query.Where("id = ?", imageID)
+	} else {
+		query = query.Where("is_primary = ?", true)
+	}
+	err := query.First(&img).Error
+	if err != nil && (imageID != 0 || !errors.Is(err, gorm.ErrRecordNotFound)) {
+		return "", ErrProductImageNotFound
+	}
+
+	var key string
+	switch size {
+	case "", "original":
+		key = img.OriginalKey
+	case "medium":
+		key = img.MediumKey
+	case "thumbnail":
+		key = img.ThumbnailKey
+	default:
+		return "", errors.New("size must be thumbnail, medium or original")
+	}
+	if key == "" {
+		return "", ErrProductImageNotFound
+	}
+	return key, nil
+}
+
+func (s *ProductService) OpenImage(key string) (*minio.Object, minio.ObjectInfo, error) {
+	return s.minioClient.OpenFile(key)
+}
@@ -1,13 +1,16 @@
 package service
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"go-admin/dto"
 	"go-admin/models"
 	"mime/multipart"
+	"strconv"
 	"strings"
 
 	"gorm.io/gorm"
@@ -25,7 +28,24 @@ func NewProductService(db *gorm.DB, minioClient *MinioService) *ProductService {
 	}
 }
 
-func (s *ProductService) Create(file *multipart.FileHeader, req dto.ProductRequest) (*dto.ProductResponse, error) {
+func (s *ProductService) WithContext(ctx context.Context) *ProductService {
+	return &ProductService{
+		db:          s.db.WithContext(ctx),
+		minioClient: s.minioClient,
+	}
+}
+
+// Create membuat produk beserta galeri gambarnya. Gambar pertama menjadi
+// gambar utama; produk boleh dibuat tanpa gambar.
+func (s *ProductService) Create(files []*multipart.FileHeader, req dto.ProductRequest) (*dto.ProductResponse, error) {
+	if err := s.validateCategory(req.CategoryID); err != nil {
+		return nil, err
+	}
+	tags, err := findOrCreateTags(s.db, req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
 	var barcode string
 	if req.Barcode == nil || *req.Barcode == "" {
 		barcode = generateBarcode()
@@ -34,15 +54,13 @@ func (s *ProductService) Create(file *multipart.FileHeader, req dto.ProductReque
 	}
 
 	// Upload file
-	fileSrc, err := file.Open()
+	images, err := s.uploadImages(files)
 	if err != nil {
 		return nil, err
 	}
-	defer fileSrc.Close()
-
-	imgUrl, err := s.minioClient.UploadFile(fileSrc, file.Size, file.Header.Get("Content-Type"))
-	if err != nil {
-		return nil, err
+	for i := range images {
+		images[i].Position = i
+		images[i].IsPrimary = i == 0
 	}
 
 	product := models.Product{
@@ -52,16 +70,27 @@ func (s *ProductService) Create(file *multipart.FileHeader, req dto.ProductReque
 		Price:       req.Price,
 		SellPrice:   req.SellPrice,
 		Stock:       req.Stock,
-		ImgUrl:      imgUrl,
+		CategoryID:  req.CategoryID,
+		Tags:        tags,
+		Images:      images,
+	}
+	if len(images) > 0 {
+		product.ImageKey = images[0].OriginalKey
 	}
 
-	result := s.db.Create(&product)
-	if result.Error != nil {
-		_ = s.minioClient.DeleteFile(imgUrl)
-		return nil, result.Error
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkBarcode(tx, product.Barcode, 0); err != nil {
+			return err
+		}
+		// Tag sudah dibuat oleh findOrCreateTags, cukup isi tabel relasinya
+		return tx.Omit("Tags.*").Create(&product).Error
+	})
+	if err != nil {
+		s.deleteImageFiles(images...)
+		return nil, err
 	}
 
-	return s.convertToResponse(&product)
+	return s.GetByID(product.ID)
 }
 
 func generateBarcode() string {
@@ -84,29 +113,15 @@ func (s *ProductService) Update(id uint, file *multipart.FileHeader, req dto.Pro
 	if err := s.db.First(&product, id).Error; err != nil {
 		return nil, errors.New("product not found")
 	}
+	if err := s.validateCategory(req.CategoryID); err != nil {
+		return nil, err
+	}
 
-	newImgUrl := product.ImgUrl
-
-	// Handle file update
+	// Handle file update: file baru menggantikan gambar utama
 	if file != nil {
-		// Upload new file
-		fileSrc, err := file.Open()
-		if err != nil {
-			return nil, err
-		}
-		defer fileSrc.Close()
-
-		newImgUrl, err = s.minioClient.UploadFile(fileSrc, file.Size, file.Header.Get("Content-Type"))
-		if err != nil {
+		if err := s.replacePrimaryImage(product.ID, file); err != nil {
 			return nil, err
 		}
-
-		// Delete old file
-		if product.ImgUrl != "" {
-			if err := s.minioClient.DeleteFile(product.ImgUrl); err != nil {
-				fmt.Printf("Warning: failed to delete old image: %v\n", err)
-			}
-		}
 	}
 
 	// Update product
@@ -115,13 +130,27 @@ func (s *ProductService) Update(id uint, file *multipart.FileHeader, req dto.Pro
 	product.Price = req.Price
 	product.SellPrice = req.SellPrice
 	product.Stock = req.Stock
-	product.ImgUrl = newImgUrl
+	product.CategoryID = req.CategoryID
 
-	if err := s.db.Save(&product).Error; err != nil {
+	if err := s.db.Omit("image_key").Save(&product).Error; err != nil {
 		return nil, err
 	}
+	// Stok produk yang punya varian mengikuti jumlah stok variannya
+	if err := syncProductStock(s.db, product.ID); err != nil {
+		return nil, err
+	}
+
+	if req.Tags != nil {
+		tags, err := findOrCreateTags(s.db, req.Tags)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.db.Model(&product).Omit("Tags.*").Association("Tags").Replace(tags); err != nil {
+			return nil, err
+		}
+	}
 
-	return s.convertToResponse(&product)
+	return s.GetByID(product.ID)
 }
 
 func (s *ProductService) Delete(id uint) error {
@@ -130,66 +159,94 @@ func (s *ProductService) Delete(id uint) error {
 		return errors.New("product not found")
 	}
 
-	// Hapus gambar jika ada
-	if product.ImgUrl != "" {
-		if err := s.minioClient.DeleteFile(product.ImgUrl); err != nil {
-			return fmt.Errorf("failed to delete image: %w", err)
+	var images []models.ProductImage
+	if err := s.db.Where("product_id = ?", product.ID).Find(&images).Error; err != nil {
+		return err
+	}
+
+	// Hapus produk beserta varian dan gambarnya dari database
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductVariant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductImage{}).Error; err != nil {
+			return err
 		}
+		return tx.Delete(&product).Error
+	})
+	if err != nil {
+		return err
 	}
 
-	// Hapus produk dari database
-	return s.db.Delete(&product).Error
+	// Hapus file gambar jika ada
+	if len(images) == 0 && product.ImageKey != "" {
+		images = append(images, models.ProductImage{OriginalKey: product.ImageKey})
+	}
+	s.deleteImageFiles(images...)
+	return nil
 }
 
 func (s *ProductService) GetByID(id uint) (*dto.ProductResponse, error) {
 	var product models.Product
-	if err := s.db.First(&product, id).Error; err != nil {
+	if err := s.db.Preload("Category").Preload("Tags").Preload("Variants").Preload("Images", models.OrderedImages).First(&product, id).Error; err != nil {
 		return nil, errors.New("product not found")
 	}
-	return s.convertToResponse(&product)
+	return convertToResponse(&product), nil
 }
 
-func (s *ProductService) GetAll(page, limit int, titleProduct string) ([]dto.ProductResponse, int64, error) {
-	var products []models.Product
-	var total int64
-
-	query := s.db.Model(&models.Product{})
+// GetAll mendukung filter tambahan filter[category]=id (termasuk semua sub
+// kategori) dan filter[tag]=nama; beberapa tag dipisah koma berarti salah
+// satunya, beberapa parameter filter[tag] berarti semuanya.
+func (s *ProductService) GetAll(query models.ListQuery) ([]dto.ProductResponse, fiber.Map, error) {
+	db := s.db
 
-	if titleProduct != "" {
-		upperTitle := strings.ToUpper(titleProduct)
-		query = query.Where("UPPER(title) LIKE ?", "%"+upperTitle+"%")
+	query, categoryFilters := query.Without("category")
+	for _, f := range categoryFilters {
+		if f.Op != "eq" && f.Op != "in" {
+			return nil, nil, errors.New("invalid filter operator for category: " + f.Op)
+		}
+		var ids []uint
+		for _, value := range strings.Split(f.Value, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+			if err != nil {
+				return nil, nil, errors.New("invalid category: " + value)
+			}
+			ids = append(ids, uint(id))
+		}
+		descendants, err := categoryDescendants(s.db, ids)
+		if err != nil {
+			return nil, nil, err
+		}
+		db = db.Where("category_id IN ?", descendants)
 	}
 
-	// Hitung total dengan filter yang sama
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	query, tagFilters := query.Without("tag")
+	for _, f := range tagFilters {
+		if f.Op != "eq" && f.Op != "in" {
+			return nil, nil, errors.New("invalid filter operator for tag: " + f.Op)
+		}
+		var names []string
+		for _, name := range strings.Split(f.Value, ",") {
+			names = append(names, normalizeTag(name))
+		}
+		db = db.Where("id IN (?)", s.db.Table("product_tags").
+			Select("product_tags.product_id").
+			Joins("JOIN tags ON tags.id = product_tags.tag_id").
+			Where("tags.name IN ?", names))
 	}
 
-	// Hitung offset
-	offset := (page - 1) * limit
-
-	// Ambil data dengan pagination dan filter
-	if err := query.Offset(offset).Limit(limit).Find(&products).Error; err != nil {
-		return nil, 0, err
+	result, err := models.Paginate(db, &models.Product{}, query)
+	if err != nil {
+		return nil, nil, err
 	}
 
+	products := result["data"].([]models.Product)
 	responses := make([]dto.ProductResponse, len(products))
-	for i, p := range products {
-		responses[i] = dto.ProductResponse{
-			ID:          p.ID,
-			Barcode:     p.Barcode,
-			Title:       p.Title,
-			Description: p.Description,
-			Price:       p.Price,
-			SellPrice:   p.SellPrice,
-			Stock:       p.Stock,
-			ImgUrl:      p.ImgUrl,
-			CreatedAt:   p.CreatedAt,
-			UpdatedAt:   p.UpdatedAt,
-		}
+	for i := range products {
+		responses[i] = *convertToResponse(&products[i])
 	}
 
-	return responses, total, nil
+	return responses, result["meta"].(fiber.Map), nil
 }
 
 //func (s *ProductService) GetAll(page, limit int) ([]dto.ProductResponse, int64, error) {
@@ -240,29 +297,44 @@ func (s *ProductService) GetAll(page, limit int, titleProduct string) ([]dto.Pro
 //	return responses, total, nil
 //}
 
-func (s *ProductService) convertToResponse(p *models.Product) (*dto.ProductResponse, error) {
-
-	var imageData []byte
-	var err error
+// convertToResponse hanya membawa url gambar; isi gambar diambil lewat
+// GET /api/products/:id/image.
+func convertToResponse(p *models.Product) *dto.ProductResponse {
+	return &dto.ProductResponse{
+		ID:           p.ID,
+		Barcode:      p.Barcode,
+		Title:        p.Title,
+		Description:  p.Description,
+		Stock:        p.Stock,
+		Price:        &p.Price,
+		SellPrice:    p.SellPrice,
+		ImgUrl:       p.ImageUrl("original"),
+		CategoryID:   p.CategoryID,
+		Category:     p.Category,
+		Tags:         tagNames(p.Tags),
+		Options:      p.Options,
+		Variants:     p.Variants,
+		Images:       p.Images,
+		ThumbnailUrl: p.ThumbnailUrl(),
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+	}
+}
 
-	if p.ImgUrl != "" {
-		imageData, err = s.minioClient.GetFile(p.ImgUrl)
-		if err != nil {
-			return nil, fmt.Errorf("error getting image: %v", err)
-		}
+func (s *ProductService) validateCategory(id *uint) error {
+	if id == nil {
+		return nil
 	}
+	if err := s.db.First(&models.Category{}, *id).Error; err != nil {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
 
-	return &dto.ProductResponse{
-		ID:          p.ID,
-		Barcode:     p.Barcode,
-		Title:       p.Title,
-		Description: p.Description,
-		Stock:       p.Stock,
-		Price:       p.Price,
-		SellPrice:   p.SellPrice,
-		ImgUrl:      p.ImgUrl,
-		ImageData:   imageData,
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
-	}, nil
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
 }
@@ -19,7 +19,7 @@ func NewProfitService(db *gorm.DB) *ProfitService {
 	return &ProfitService{DB: db}
 }
 
-func (s *ProfitService) FilterProfits(startDate, endDate string) ([]models.Profit, float64, error) {
+func (s *ProfitService) FilterProfits(scope models.DataScope, startDate, endDate string) ([]models.Profit, float64, error) {
 	// Parse tanggal dari string ke time.Time
 	start, err := time.Parse("2006-01-02", startDate)
 	if err != nil {
@@ -32,6 +32,7 @@ func (s *ProfitService) FilterProfits(startDate, endDate string) ([]models.Profi
 
 	var profits []models.Profit
 	if err := s.DB.Preload("Transaction").
+		Scopes(scope.Profits).
 		Where("DATE(created_at) BETWEEN ? AND ?", start, end).
 		Find(&profits).Error; err != nil {
 		return nil, 0, err
@@ -39,6 +40,7 @@ func (s *ProfitService) FilterProfits(startDate, endDate string) ([]models.Profi
 
 	var total_profit float64
 	if err := s.DB.Model(&models.Profit{}).
+		Scopes(scope.Profits).
 		Where("DATE(created_at) BETWEEN ? AND ?", start, end).
 		Select("SUM(total)").Scan(&total_profit).Error; err != nil {
 		return nil, 0, err
@@ -47,8 +49,8 @@ func (s *ProfitService) FilterProfits(startDate, endDate string) ([]models.Profi
 	return profits, total_profit, nil
 }
 
-func (s *ProfitService) ExportExcel(startDate, endDate string) (*excelize.File, error) {
-	profits, _, err := s.FilterProfits(startDate, endDate)
+func (s *ProfitService) ExportExcel(scope models.DataScope, startDate, endDate string) (*excelize.File, error) {
+	profits, _, err := s.FilterProfits(scope, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
@@ -117,8 +119,8 @@ func (s *ProfitService) totalStyle(f *excelize.File) int {
 	return style
 }
 
-func (s *ProfitService) ExportPDF(startDate, endDate string) ([]byte, error) {
-	profits, totalProfit, err := s.FilterProfits(startDate, endDate)
+func (s *ProfitService) ExportPDF(scope models.DataScope, startDate, endDate string) ([]byte, error) {
+	profits, totalProfit, err := s.FilterProfits(scope, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-admin/dto"
+	"go-admin/models"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultPaymentTermDays dipakai jika customer tidak punya termin pembayaran.
+const DefaultPaymentTermDays = 30
+
+var (
+	ErrReceivableNotFound    = errors.New("receivable not found")
+	ErrReceivableSettled     = errors.New("receivable is already settled")
+	ErrReceivableHasPayments = errors.New("credit sale already has payments")
+	ErrCreditWithoutCustomer = errors.New("credit sales require a registered customer")
+	ErrCreditLimitExceeded   = errors.New("credit limit exceeded")
+)
+
+type ReceivableService struct {
+	db *gorm.DB
+}
+
+func NewReceivableService(db *gorm.DB) *ReceivableService {
+	return &ReceivableService{db: db}
+}
+
+func (s *ReceivableService) WithContext(ctx context.Context) *ReceivableService {
+	return &ReceivableService{db: s.db.WithContext(ctx)}
+}
+
+func (s *ReceivableService) GetReceivables(query models.ListQuery) (fiber.Map, error) {
+	return models.Paginate(s.db, &models.Receivable{}, query)
+}
+
+func (s *ReceivableService) GetReceivable(id uint) (*models.Receivable, error) {
+	var receivable models.Receivable
+	err := s.db.Preload("Customer").
+		Preload("Transaction").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).
+		First(&receivable, id).Error
+	if err != nil {
+		return nil, ErrReceivableNotFound
+	}
+	return &receivable, nil
+}
+
+// RecordPayment mencatat pembayaran piutang, boleh sebagian. Pembayaran
+// tidak boleh melebihi sisa piutang. Baris piutang dikunci supaya dua
+// pembayaran bersamaan tidak membaca sisa yang sama.
+func (s *ReceivableService) RecordPayment(id, userID uint, request dto.ReceivablePaymentRequest) (*models.Receivable, error) {
+	if request.Amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var receivable models.Receivable
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&receivable, id).Error; err != nil {
+			return ErrReceivableNotFound
+		}
+		if receivable.SettledAt != nil {
+			return ErrReceivableSettled
+		}
+		if request.Amount > receivable.Balance {
+			return fmt.Errorf("amount exceeds outstanding balance %.2f", receivable.Balance)
+		}
+
+		payment := models.ReceivablePayment{
+			ReceivableID: receivable.ID,
+			UserID:       userID,
+			Amount:       request.Amount,
+			Note:         request.Note,
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"paid": gorm.Expr("paid + ?", request.Amount)}
+		if receivable.Paid+request.Amount >= receivable.Amount {
+			updates["settled_at"] = time.Now()
+		}
+		return tx.Model(&receivable).Omit("Payments").Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetReceivable(id)
+}
+
+// Aging mengelompokkan sisa piutang yang belum lunas per customer berdasarkan
+// hari lewat jatuh tempo: belum jatuh tempo (current), 1-30, 31-60 dan lebih
+// dari 60 hari. Kolom overdue adalah jumlah semua bagian yang sudah lewat
+// jatuh tempo. Tanggal dibandingkan dengan models.Today seperti
+// Receivable.Overdue.
+func (s *ReceivableService) Aging() (*dto.ReceivableAgingReport, error) {
+	var receivables []models.Receivable
+	if err := s.db.Preload("Customer").Where("settled_at IS NULL").Find(&receivables).Error; err != nil {
+		return nil, err
+	}
+
+	today := models.Today()
+	report := &dto.ReceivableAgingReport{Date: today, Customers: []dto.ReceivableAging{}}
+	byCustomer := make(map[uint]*dto.ReceivableAging)
+
+	for _, receivable := range receivables {
+		row, ok := byCustomer[receivable.CustomerID]
+		if !ok {
+			row = &dto.ReceivableAging{CustomerID: receivable.CustomerID}
+			if receivable.Customer != nil {
+				row.Name = receivable.Customer.Name
+			}
+			byCustomer[receivable.CustomerID] = row
+		}
+
+		balance := receivable.Balance
+		due := receivable.DueDate
+		age := int(today.Sub(time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC)).Hours() / 24)
+		for _, r := range []*dto.ReceivableAging{row, &report.Totals} {
+			switch {
+			case age <= 0:
+				r.Current += balance
+			case age <= 30:
+				r.Days1To30 += balance
+			case age <= 60:
+				r.Days31To60 += balance
+			default:
+				r.Over60 += balance
+			}
+			r.Total += balance
+			if receivable.Overdue {
+				r.Overdue += balance
+			}
+		}
+	}
+
+	for _, row := range byCustomer {
+		report.Customers = append(report.Customers, *row)
+	}
+	sort.Slice(report.Customers, func(i, j int) bool {
+		if report.Customers[i].Total != report.Customers[j].Total {
+			return report.Customers[i].Total > report.Customers[j].Total
+		}
+		return report.Customers[i].CustomerID < report.Customers[j].CustomerID
+	})
+
+	return report, nil
+}
+
+// customerOutstanding menjumlahkan sisa piutang customer yang belum lunas.
+func customerOutstanding(tx *gorm.DB, customerID uint) (float64, error) {
+	var outstanding float64
+	err := tx.Model(&models.Receivable{}).
+		Select("COALESCE(SUM(amount - paid), 0)").
+		Where("customer_id = ? AND settled_at IS NULL", customerID).
+		Scan(&outstanding).Error
+	return outstanding, err
+}
+
+// createReceivable memastikan piutang baru tidak melewati limit kredit
+// customer, lalu mencatatnya untuk transaksi tersebut. Baris customer dikunci
+// supaya dua penjualan kredit bersamaan tidak sama-sama lolos dari limit.
+func createReceivable(tx *gorm.DB, transaction *models.Transaction, dueDate *models.Date) error {
+	var customer models.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, transaction.CustomerID).Error; err != nil {
+		return ErrCustomerNotFound
+	}
+
+	outstanding, err := customerOutstanding(tx, customer.Id)
+	if err != nil {
+		return err
+	}
+	if outstanding+transaction.CreditAmount > customer.CreditLimit {
+		return fmt.Errorf("%w: limit %.2f, outstanding %.2f, requested %.2f",
+			ErrCreditLimitExceeded, customer.CreditLimit, outstanding, transaction.CreditAmount)
+	}
+
+	today := models.Today()
+	var due models.Date
+	if dueDate != nil && !dueDate.IsZero() {
+		if dueDate.Before(today.Time) {
+			return errors.New("due_date must not be in the past")
+		}
+		due = *dueDate
+	} else {
+		days := customer.PaymentTermDays
+		if days <= 0 {
+			days = DefaultPaymentTermDays
+		}
+		due = models.Date{Time: today.AddDate(0, 0, days)}
+	}
+
+	receivable := models.Receivable{
+		CustomerID:    customer.Id,
+		TransactionID: transaction.ID,
+		Amount:        transaction.CreditAmount,
+		DueDate:       due,
+	}
+	return tx.Create(&receivable).Error
+}
+
+// removeTransactionReceivable menghapus piutang dari transaksi yang di-void.
+// Transaksi yang piutangnya sudah dibayar sebagian tidak bisa di-void.
+func removeTransactionReceivable(tx *gorm.DB, transactionID uint) error {
+	var receivable models.Receivable
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("transaction_id = ?", transactionID).
+		Take(&receivable).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if receivable.Paid > 0 {
+		return ErrReceivableHasPayments
+	}
+	return tx.Delete(&receivable).Error
+}
+
+// attachCredit mengisi sisa piutang dan tanda overdue pada customer.
+func attachCredit(db *gorm.DB, customers []models.Customer) error {
+	if len(customers) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(customers))
+	for i, customer := range customers {
+		ids[i] = customer.Id
+	}
+
+	var rows []struct {
+		CustomerID    uint
+		Outstanding   float64
+		OverdueAmount float64
+	}
+	err := db.Model(&models.Receivable{}).
+		Select("customer_id, SUM(amount - paid) AS outstanding, "+
+			"SUM(CASE WHEN due_date < ? THEN amount - paid ELSE 0 END) AS overdue_amount", models.Today()).
+		Where("customer_id IN ? AND settled_at IS NULL", ids).
+		Group("customer_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	byCustomer := make(map[uint]int, len(customers))
+	for i, customer := range customers {
+		byCustomer[customer.Id] = i
+	}
+	for _, row := range rows {
+		customer := &customers[byCustomer[row.CustomerID]]
+		customer.Outstanding = row.Outstanding
+		customer.OverdueAmount = row.OverdueAmount
+		customer.Overdue = row.OverdueAmount > 0
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+
+	"go-admin/models"
+)
+
+func TestAgingUsesDueDate(t *testing.T) {
+	db := openTestDB(t)
+	customer := models.Customer{Name: "Budi"}
+	db.Create(&customer)
+
+	today := models.Today()
+	for i, days := range []int{45, 0, -10, -45, -90} {
+		db.Create(&models.Receivable{
+			CustomerID:    customer.Id,
+			TransactionID: uint(i + 1),
+			Amount:        100,
+			DueDate:       models.Date{Time: today.AddDate(0, 0, days)},
+		})
+	}
+
+	report, err := NewReceivableService(db).Aging()
+	if err != nil {
+		t.Fatalf("aging: %v", err)
+	}
+	got := report.Totals
+	if got.Current != 200 || got.Days1To30 != 100 || got.Days31To60 != 100 || got.Over60 != 100 {
+		t.Errorf("buckets = %+v", got)
+	}
+	if got.Total != 500 || got.Overdue != 300 {
+		t.Errorf("total = %v, overdue = %v", got.Total, got.Overdue)
+	}
+}
@@ -1,10 +1,15 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"go-admin/database"
+	"go-admin/dto"
 	"go-admin/models"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -18,6 +23,10 @@ func NewRoleService(db *gorm.DB) *RoleService {
 	return &RoleService{db: db}
 }
 
+func (s *RoleService) WithContext(ctx context.Context) *RoleService {
+	return &RoleService{db: s.db.WithContext(ctx)}
+}
+
 func (s *RoleService) GetAllRoles() ([]models.Role, error) {
 	var roles []models.Role
 	if err := s.db.Find(&roles).Error; err != nil {
@@ -57,8 +66,18 @@ func (s *RoleService) CreateRole(roleDto fiber.Map) (*models.Role, error) {
 		permissions = append(permissions, models.Permission{Id: uint(id)})
 	}
 
+	dataScope := models.DataScopeAll
+	if scope, ok := roleDto["data_scope"].(string); ok && scope != "" {
+		if !models.IsValidDataScope(scope) {
+			tx.Rollback()
+			return nil, errors.New("invalid data scope: " + scope)
+		}
+		dataScope = scope
+	}
+
 	role := models.Role{
 		Name:        name,
+		DataScope:   dataScope,
 		Permissions: permissions,
 	}
 
@@ -73,6 +92,11 @@ func (s *RoleService) CreateRole(roleDto fiber.Map) (*models.Role, error) {
 		return nil, err
 	}
 
+	if err := recordRolePermissions(tx, role.Id, nil, role.Permissions); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
@@ -102,11 +126,21 @@ func (s *RoleService) UpdateRole(id uint, roleDto fiber.Map) (*models.Role, erro
 		return nil, errors.New("role not found")
 	}
 
+	previousPermissions := role.Permissions
+
 	name, ok := roleDto["name"].(string)
 	if ok {
 		role.Name = name
 	}
 
+	if scope, ok := roleDto["data_scope"].(string); ok && scope != "" {
+		if !models.IsValidDataScope(scope) {
+			tx.Rollback()
+			return nil, errors.New("invalid data scope: " + scope)
+		}
+		role.DataScope = scope
+	}
+
 	if err := tx.Model(&role).Updates(role).Error; err != nil {
 		tx.Rollback()
 		return nil, err
@@ -137,6 +171,11 @@ func (s *RoleService) UpdateRole(id uint, roleDto fiber.Map) (*models.Role, erro
 		return nil, err
 	}
 
+	if err := recordRolePermissions(tx, role.Id, previousPermissions, role.Permissions); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
@@ -148,7 +187,7 @@ func (s *RoleService) DeleteRole(id uint) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
 		// Cek apakah role ada
 		var role models.Role
-		if err := tx.First(&role, id).Error; err != nil {
+		if err := tx.Preload("Permissions").First(&role, id).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return errors.New("role not found")
 			}
@@ -159,6 +198,9 @@ func (s *RoleService) DeleteRole(id uint) error {
 		if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
 			return errors.New("failed to clear role permissions")
 		}
+		if err := recordRolePermissions(tx, role.Id, role.Permissions, nil); err != nil {
+			return err
+		}
 
 		// Hapus role
 		if err := tx.Delete(&role).Error; err != nil {
@@ -168,3 +210,186 @@ func (s *RoleService) DeleteRole(id uint) error {
 		return nil
 	})
 }
+
+func (s *RoleService) ExportRoles() (*dto.RoleDocument, error) {
+	var roles []models.Role
+	if err := s.db.Preload("Permissions").Order("id").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	doc := &dto.RoleDocument{Roles: make([]dto.RoleDefinition, 0, len(roles))}
+	for _, role := range roles {
+		doc.Roles = append(doc.Roles, dto.RoleDefinition{
+			Name:        role.Name,
+			DataScope:   role.DataScope,
+			Permissions: permissionNames(role.Permissions),
+		})
+	}
+
+	return doc, nil
+}
+
+// ImportRoles menyamakan role di database dengan isi dokumen. Role yang tidak
+// ada di dokumen dibiarkan. Jika dryRun true, hanya perbedaannya yang
+// dikembalikan tanpa mengubah database. Import ulang dokumen yang sama
+// tidak menghasilkan perubahan.
+func (s *RoleService) ImportRoles(doc *dto.RoleDocument, dryRun bool) ([]dto.RoleChange, error) {
+	var changes []dto.RoleChange
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var permissions []models.Permission
+		if err := tx.Find(&permissions).Error; err != nil {
+			return err
+		}
+		permissionByName := make(map[string]models.Permission, len(permissions))
+		for _, p := range permissions {
+			permissionByName[p.Name] = p
+		}
+
+		seen := make(map[string]bool)
+		for _, def := range doc.Roles {
+			if def.Name == "" {
+				return errors.New("role name is required")
+			}
+			if seen[def.Name] {
+				return errors.New("duplicate role in document: " + def.Name)
+			}
+			seen[def.Name] = true
+
+			if def.DataScope == "" {
+				def.DataScope = models.DataScopeAll
+			}
+			if !models.IsValidDataScope(def.DataScope) {
+				return fmt.Errorf("invalid data scope for role %s: %s", def.Name, def.DataScope)
+			}
+
+			wanted := make([]models.Permission, 0, len(def.Permissions))
+			seenPermission := make(map[string]bool, len(def.Permissions))
+			for _, name := range def.Permissions {
+				p, ok := permissionByName[name]
+				if !ok {
+					return fmt.Errorf("unknown permission for role %s: %s", def.Name, name)
+				}
+				if seenPermission[name] {
+					return fmt.Errorf("duplicate permission for role %s: %s", def.Name, name)
+				}
+				seenPermission[name] = true
+				wanted = append(wanted, p)
+			}
+
+			var role models.Role
+			err := tx.Preload("Permissions").Where("name = ?", def.Name).First(&role).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				added := append([]string(nil), def.Permissions...)
+				sort.Strings(added)
+				changes = append(changes, dto.RoleChange{
+					Role:           def.Name,
+					Action:         dto.RoleActionCreate,
+					DataScopeTo:    def.DataScope,
+					AddPermissions: added,
+				})
+				if dryRun {
+					continue
+				}
+
+				role = models.Role{Name: def.Name, DataScope: def.DataScope, Permissions: wanted}
+				if err := tx.Create(&role).Error; err != nil {
+					return err
+				}
+				if err := recordRolePermissions(tx, role.Id, nil, wanted); err != nil {
+					return err
+				}
+				continue
+			}
+
+			change := diffRole(role, def)
+			changes = append(changes, change)
+			if dryRun || change.Action == dto.RoleActionUnchanged {
+				continue
+			}
+
+			if role.DataScope != def.DataScope {
+				if err := tx.Model(&role).Update("data_scope", def.DataScope).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&role).Association("Permissions").Replace(wanted); err != nil {
+				return err
+			}
+			if err := recordRolePermissions(tx, role.Id, role.Permissions, wanted); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func diffRole(role models.Role, def dto.RoleDefinition) dto.RoleChange {
+	current := make(map[string]bool, len(role.Permissions))
+	for _, p := range role.Permissions {
+		current[p.Name] = true
+	}
+	wanted := make(map[string]bool, len(def.Permissions))
+	for _, name := range def.Permissions {
+		wanted[name] = true
+	}
+
+	change := dto.RoleChange{Role: role.Name, Action: dto.RoleActionUnchanged}
+	for name := range wanted {
+		if !current[name] {
+			change.AddPermissions = append(change.AddPermissions, name)
+		}
+	}
+	for name := range current {
+		if !wanted[name] {
+			change.RemovePermissions = append(change.RemovePermissions, name)
+		}
+	}
+	sort.Strings(change.AddPermissions)
+	sort.Strings(change.RemovePermissions)
+
+	if role.DataScope != def.DataScope {
+		change.DataScopeFrom = role.DataScope
+		change.DataScopeTo = def.DataScope
+	}
+
+	if change.DataScopeTo != "" || len(change.AddPermissions) > 0 || len(change.RemovePermissions) > 0 {
+		change.Action = dto.RoleActionUpdate
+	}
+
+	return change
+}
+
+// recordRolePermissions mencatat perubahan permission role ke audit log,
+// karena perubahan relasi many2many tidak tertangkap callback model.
+func recordRolePermissions(db *gorm.DB, roleID uint, before, after []models.Permission) error {
+	beforeNames := permissionNames(before)
+	afterNames := permissionNames(after)
+	if strings.Join(beforeNames, ",") == strings.Join(afterNames, ",") {
+		return nil
+	}
+
+	return database.RecordAudit(db, "update_permissions", models.Role{}.AuditEntity(), roleID,
+		fiber.Map{"permissions": beforeNames},
+		fiber.Map{"permissions": afterNames},
+	)
+}
+
+func permissionNames(permissions []models.Permission) []string {
+	names := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return names
+}
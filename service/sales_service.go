@@ -5,7 +5,9 @@ import (
 	"github.com/jung-kurt/gofpdf"
 	"github.com/xuri/excelize/v2"
 	"go-admin/models"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -19,7 +21,7 @@ func NewSalesService(db *gorm.DB) *SalesService {
 	return &SalesService{db: db}
 }
 
-func (s *SalesService) FilterSales(startDate, endDate string) ([]models.Transaction, float64, error) {
+func (s *SalesService) FilterSales(scope models.DataScope, startDate, endDate string) ([]models.Transaction, float64, error) {
 	var sales []models.Transaction
 	var total float64
 
@@ -34,7 +36,11 @@ func (s *SalesService) FilterSales(startDate, endDate string) ([]models.Transact
 	}
 
 	// Query transactions with relations
-	if err := s.db.Preload("User").Preload("Customer").Preload("TransactionDetails").
+	// Unscoped supaya kasir yang sudah dihapus tetap tampil namanya
+	if err := s.db.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Preload("Customer").Preload("TransactionDetails").
+		Scopes(scope.Transactions("user_id"), models.NotVoided("voided_at")).
 		Where("DATE(created_at) >= ? AND DATE(created_at) <= ?", startDate, endDate).
 		Find(&sales).Error; err != nil {
 		return nil, 0, err
@@ -43,6 +49,7 @@ func (s *SalesService) FilterSales(startDate, endDate string) ([]models.Transact
 	// Calculate total sales
 	if err := s.db.Model(&models.Transaction{}).
 		Select("SUM(grand_total)").
+		Scopes(scope.Transactions("user_id"), models.NotVoided("voided_at")).
 		Where("DATE(created_at) >= ? AND DATE(created_at) <= ?", startDate, endDate).
 		Scan(&total).Error; err != nil {
 		return nil, 0, err
@@ -51,8 +58,83 @@ func (s *SalesService) FilterSales(startDate, endDate string) ([]models.Transact
 	return sales, total, nil
 }
 
-func (s *SalesService) ExportExcel(startDate, endDate string) (*excelize.File, error) {
-	sales, _, err := s.FilterSales(startDate, endDate)
+// uncategorized adalah nama kategori untuk produk tanpa kategori di laporan.
+const uncategorized = "Tanpa Kategori"
+
+type CategorySales struct {
+	CategoryID uint // 0 untuk produk tanpa kategori
+	Category   string
+	Qty        float64
+	Total      float64
+}
+
+// salesByCategory menghitung penjualan per kategori produk dari detail
+// transaksi (harga x qty, sebelum diskon), sekaligus daftar kategori per
+// transaksi. Kategori dikelompokkan per id dan ditampilkan dengan nama
+// lengkapnya (lihat categoryPaths).
+func (s *SalesService) salesByCategory(scope models.DataScope, startDate, endDate string) ([]CategorySales, map[uint][]string, error) {
+	var rows []struct {
+		TransactionID uint
+		CategoryID    *uint
+		Qty           float64
+		Total         float64
+	}
+	err := s.db.Table("transaction_details").
+		Select("transaction_details.transaction_id, products.category_id, "+
+			"SUM(transaction_details.qty) AS qty, SUM(transaction_details.qty * transaction_details.price) AS total").
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
+		Joins("JOIN products ON products.id = transaction_details.product_id").
+		Scopes(scope.Transactions("transactions.user_id"), models.NotVoided("transactions.voided_at")).
+		Where("DATE(transactions.created_at) >= ? AND DATE(transactions.created_at) <= ?", startDate, endDate).
+		Group("transaction_details.transaction_id, products.category_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paths, err := categoryPaths(s.db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byCategory := make(map[uint]*CategorySales)
+	byTransaction := make(map[uint][]string)
+	for _, row := range rows {
+		var id uint
+		name := uncategorized
+		if row.CategoryID != nil {
+			if path, ok := paths[*row.CategoryID]; ok {
+				id, name = *row.CategoryID, path
+			}
+		}
+		summary, ok := byCategory[id]
+		if !ok {
+			summary = &CategorySales{CategoryID: id, Category: name}
+			byCategory[id] = summary
+		}
+		summary.Qty += row.Qty
+		summary.Total += row.Total
+		byTransaction[row.TransactionID] = append(byTransaction[row.TransactionID], name)
+	}
+
+	summaries := make([]CategorySales, 0, len(byCategory))
+	for _, summary := range byCategory {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Total > summaries[j].Total })
+	for id := range byTransaction {
+		sort.Strings(byTransaction[id])
+	}
+
+	return summaries, byTransaction, nil
+}
+
+func (s *SalesService) ExportExcel(scope models.DataScope, startDate, endDate string) (*excelize.File, error) {
+	sales, _, err := s.FilterSales(scope, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	categorySales, categories, err := s.salesByCategory(scope, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +145,7 @@ func (s *SalesService) ExportExcel(startDate, endDate string) (*excelize.File, e
 	f.DeleteSheet("Sheet1") //Hapus sheet default
 
 	//Set header
-	header := []string{"No", "Date", "Invoice", "Cashier", "Customer", "Discount", "Total"}
+	header := []string{"No", "Date", "Invoice", "Cashier", "Customer", "Categories", "Discount", "Total"}
 	for i, h := range header {
 		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
 		f.SetCellValue(sheet, cell, h)
@@ -89,6 +171,7 @@ func (s *SalesService) ExportExcel(startDate, endDate string) (*excelize.File, e
 			sale.Invoice,
 			cashier,
 			customer,
+			strings.Join(categories[sale.ID], ", "),
 			sale.Discount,
 			sale.GrandTotal,
 		}
@@ -102,14 +185,31 @@ func (s *SalesService) ExportExcel(startDate, endDate string) (*excelize.File, e
 
 	totalRow := len(sales) + 2
 	f.SetCellValue(sheet, "A"+strconv.Itoa(totalRow), "TOTAL SALES")
-	f.MergeCell(sheet, "A"+strconv.Itoa(totalRow), "F"+strconv.Itoa(totalRow))
-	f.SetCellValue(sheet, "G"+strconv.Itoa(totalRow), grandTotal)
-	f.SetCellStyle(sheet, "A"+strconv.Itoa(totalRow), "G"+strconv.Itoa(totalRow), s.totalStyle(f))
+	f.MergeCell(sheet, "A"+strconv.Itoa(totalRow), "G"+strconv.Itoa(totalRow))
+	f.SetCellValue(sheet, "H"+strconv.Itoa(totalRow), grandTotal)
+	f.SetCellStyle(sheet, "A"+strconv.Itoa(totalRow), "H"+strconv.Itoa(totalRow), s.totalStyle(f))
 
 	for i := range header {
 		col, _ := excelize.ColumnNumberToName(i + 1)
 		f.SetColWidth(sheet, col, col, 20)
 	}
+
+	// Sheet ringkasan per kategori
+	categorySheet := "Sales by Category"
+	f.NewSheet(categorySheet)
+	for i, h := range []string{"Category", "Qty", "Total"} {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(categorySheet, cell, h)
+		f.SetCellStyle(categorySheet, cell, cell, s.headerStyle(f))
+	}
+	for i, summary := range categorySales {
+		row := strconv.Itoa(i + 2)
+		f.SetCellValue(categorySheet, "A"+row, summary.Category)
+		f.SetCellValue(categorySheet, "B"+row, summary.Qty)
+		f.SetCellValue(categorySheet, "C"+row, summary.Total)
+	}
+	f.SetColWidth(categorySheet, "A", "C", 20)
+
 	return f, nil
 }
 
@@ -131,8 +231,12 @@ func (s *SalesService) totalStyle(f *excelize.File) int {
 	return style
 }
 
-func (s *SalesService) ExportPDF(startDate, endDate string) ([]byte, error) {
-	sales, total, err := s.FilterSales(startDate, endDate)
+func (s *SalesService) ExportPDF(scope models.DataScope, startDate, endDate string) ([]byte, error) {
+	sales, total, err := s.FilterSales(scope, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	categorySales, _, err := s.salesByCategory(scope, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
@@ -206,6 +310,23 @@ func (s *SalesService) ExportPDF(startDate, endDate string) ([]byte, error) {
 
 	pdf.CellFormat(colWidths[6], 10, "Rp. "+strconv.FormatFloat(total, 'f', 0, 64), "1", 1, "C", false, 0, "")
 
+	// Ringkasan per kategori
+	pdf.Ln(5)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.CellFormat(0, 10, "SALES BY CATEGORY", "", 1, "L", false, 0, "")
+	categoryWidths := []float64{80, 40, 50}
+	for i, header := range []string{"Category", "Qty", "Total"} {
+		pdf.CellFormat(categoryWidths[i], 10, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 10)
+	for _, summary := range categorySales {
+		pdf.CellFormat(categoryWidths[0], 10, summary.Category, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(categoryWidths[1], 10, strconv.FormatFloat(summary.Qty, 'f', -1, 64), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(categoryWidths[2], 10, "Rp. "+strconv.FormatFloat(summary.Total, 'f', 0, 64), "1", 1, "C", false, 0, "")
+	}
+
 	// Simpan ke buffer
 	var buf bytes.Buffer
 	err = pdf.Output(&buf)
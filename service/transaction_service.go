@@ -1,10 +1,16 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"go-admin/database"
+	"go-admin/dto"
 	"go-admin/models"
 	"gorm.io/gorm"
+	"strings"
+	"time"
 )
 
 type TransactionService struct {
@@ -15,6 +21,10 @@ func NewTransactionService(db *gorm.DB) *TransactionService {
 	return &TransactionService{db: db}
 }
 
+func (s *TransactionService) WithContext(ctx context.Context) *TransactionService {
+	return &TransactionService{db: s.db.WithContext(ctx)}
+}
+
 func (s *TransactionService) ValidateCartOwnership(userID uint, cartID uint) error {
 	var cart models.Cart
 	if err := s.db.Where("id = ? AND user_id = ?", cartID, userID).First(&cart).Error; err != nil {
@@ -26,16 +36,31 @@ func (s *TransactionService) ValidateCartOwnership(userID uint, cartID uint) err
 	return nil
 }
 
-func (s *TransactionService) SearchProduct(barcode string) (*models.Product, error) {
+// SearchProduct mencari produk berdasarkan barcode. Jika barcode (atau SKU)
+// milik varian, produk induk dikembalikan bersama varian tersebut.
+func (s *TransactionService) SearchProduct(barcode string) (*models.Product, *models.ProductVariant, error) {
 	var product models.Product
-	result := s.db.Where("barcode = ?", barcode).First(&product)
-	if result.Error != nil {
-		return nil, result.Error
+	err := s.db.Preload("Variants").Where("barcode = ?", barcode).First(&product).Error
+	if err == nil {
+		return &product, nil, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	var variant models.ProductVariant
+	if err := s.db.Where("barcode = ? OR (sku <> '' AND sku = ?)", barcode, barcode).First(&variant).Error; err != nil {
+		return nil, nil, err
 	}
-	return &product, nil
+	if err := s.db.Preload("Variants").First(&product, variant.ProductID).Error; err != nil {
+		return nil, nil, err
+	}
+	return &product, &variant, nil
 }
 
-func (s *TransactionService) AddToCart(userID uint, productID uint, qty float64) error {
+// AddToCart menambah produk ke cart. Produk yang punya varian wajib
+// menyertakan variantID, dan harga diambil dari varian.
+func (s *TransactionService) AddToCart(userID uint, productID uint, variantID *uint, qty float64) error {
 	var product models.Product
 	if err := s.db.First(&product, productID).Error; err != nil {
 		return err
@@ -48,6 +73,23 @@ func (s *TransactionService) AddToCart(userID uint, productID uint, qty float64)
 		Price:     product.SellPrice,
 	}
 
+	if variantID != nil {
+		var variant models.ProductVariant
+		if err := s.db.Where("product_id = ?", product.ID).First(&variant, *variantID).Error; err != nil {
+			return ErrVariantNotFound
+		}
+		cart.VariantID = &variant.ID
+		cart.Price = variant.SellPrice
+	} else {
+		var count int64
+		if err := s.db.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrVariantRequired
+		}
+	}
+
 	return s.db.Create(&cart).Error
 }
 
@@ -57,7 +99,7 @@ func (s *TransactionService) DestroyCart(cartID uint) error {
 
 func (s *TransactionService) GetCart(userID uint) ([]models.Cart, float64, error) {
 	var carts []models.Cart
-	result := s.db.Preload("Product").Where("user_id = ?", userID).Find(&carts)
+	result := s.db.Preload("Product").Preload("Variant").Where("user_id = ?", userID).Find(&carts)
 	if result.Error != nil {
 		return nil, 0, result.Error
 	}
@@ -70,7 +112,26 @@ func (s *TransactionService) GetCart(userID uint) ([]models.Cart, float64, error
 	return carts, total, nil
 }
 
-func (s *TransactionService) PayOrder(userID, customerID uint, discountPercent, cash float64) (*models.Transaction, error) {
+// ListCarts menampilkan isi cart semua kasir sesuai data scope user.
+func (s *TransactionService) ListCarts(scope models.DataScope, query models.ListQuery) ([]models.Cart, fiber.Map, error) {
+	result, err := models.Paginate(s.db.Scopes(scope.Transactions("user_id")), &models.Cart{}, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result["data"].([]models.Cart), result["meta"].(fiber.Map), nil
+}
+
+func (s *TransactionService) ListTransactions(scope models.DataScope, query models.ListQuery) (fiber.Map, error) {
+	return models.Paginate(s.db.Scopes(scope.Transactions("user_id")), &models.Transaction{}, query)
+}
+
+// PayOrder membuat transaksi dari cart user. Poin loyalty yang ditukar
+// menjadi potongan harga, dan poin baru diberikan dari jumlah yang dibayar.
+func (s *TransactionService) PayOrder(userID uint, request dto.PayOrderRequest) (*models.Transaction, error) {
+	customerID := request.CustomerID
+	discountPercent := request.Discount
+	cash := request.Cash
+
 	carts, total, err := s.GetCart(userID)
 	if err != nil {
 		return nil, err
@@ -79,9 +140,33 @@ func (s *TransactionService) PayOrder(userID, customerID uint, discountPercent,
 		return nil, errors.New("cart is empty")
 	}
 
+	if request.RedeemPoints < 0 {
+		return nil, errors.New("redeem_points must not be negative")
+	}
+	if request.RedeemPoints > 0 && customerID == 0 {
+		return nil, ErrPointsWithoutCustomer
+	}
+
 	discountAmount := (discountPercent / 100) * total
-	grandTotal := total - discountAmount
+	pointsDiscount := float64(request.RedeemPoints) * LoyaltyPointValue
+	if pointsDiscount > total-discountAmount {
+		return nil, errors.New("redeemed points exceed order total")
+	}
+	grandTotal := total - discountAmount - pointsDiscount
 	change := cash - grandTotal
+
+	// Penjualan kredit: kekurangan bayar menjadi piutang customer
+	var creditAmount float64
+	if request.Credit && change < 0 {
+		if customerID == 0 {
+			return nil, ErrCreditWithoutCustomer
+		}
+		if cash < 0 {
+			return nil, errors.New("cash must not be negative")
+		}
+		creditAmount = -change
+		change = 0
+	}
 	if change < 0 {
 		return nil, errors.New("cash is not enough")
 	}
@@ -101,20 +186,45 @@ func (s *TransactionService) PayOrder(userID, customerID uint, discountPercent,
 			tx.Rollback()
 			return nil, errors.New("product not found")
 		}
+		if cart.VariantID != nil {
+			var variant models.ProductVariant
+			if err := tx.First(&variant, *cart.VariantID).Error; err != nil {
+				tx.Rollback()
+				return nil, ErrVariantNotFound
+			}
+			if variant.Stock < cart.Qty {
+				tx.Rollback()
+				return nil, fmt.Errorf("stock not enough for product '%s' (%s)", product.Title, variantLabel(variant))
+			}
+			continue
+		}
 		if product.Stock < cart.Qty {
 			tx.Rollback()
 			return nil, fmt.Errorf("stock not enough for product '%s'", product.Title)
 		}
 	}
 
+	var pointsEarned int64
+	if customerID != 0 && total > 0 {
+		pointsEarned, err = calculatePoints(tx, carts, grandTotal/total)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	// Create Transaction
 	transaction := models.Transaction{
-		UserID:     userID,
-		CustomerID: customerID,
-		Cash:       cash,
-		Change:     change,
-		Discount:   discountAmount,
-		GrandTotal: grandTotal,
+		UserID:         userID,
+		CustomerID:     customerID,
+		Cash:           cash,
+		Change:         change,
+		CreditAmount:   creditAmount,
+		Discount:       discountAmount,
+		GrandTotal:     grandTotal,
+		PointsEarned:   pointsEarned,
+		PointsRedeemed: request.RedeemPoints,
+		PointsDiscount: pointsDiscount,
 	}
 
 	if err := tx.Create(&transaction).Error; err != nil {
@@ -122,6 +232,51 @@ func (s *TransactionService) PayOrder(userID, customerID uint, discountPercent,
 		return nil, err
 	}
 
+	if creditAmount > 0 {
+		if err := createReceivable(tx, &transaction, request.DueDate); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	// Poin loyalty
+	if customerID != 0 {
+		now := time.Now()
+		if err := lockCustomer(tx, customerID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := expirePoints(tx, customerID, now); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if request.RedeemPoints > 0 {
+			if err := redeemPoints(tx, customerID, transaction.ID, request.RedeemPoints); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+		if pointsEarned > 0 {
+			if err := earnPoints(tx, customerID, transaction.ID, pointsEarned, now); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+
+	if discountAmount > 0 {
+		err := database.RecordAudit(tx, "discount", transaction.AuditEntity(), transaction.ID, nil, map[string]float64{
+			"discount_percent": discountPercent,
+			"discount":         discountAmount,
+			"total":            total,
+			"grand_total":      grandTotal,
+		})
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	// Create Detail dan Profit
 	for _, cart := range carts {
 		// Ambil data produk untuk harga beli
@@ -131,10 +286,22 @@ func (s *TransactionService) PayOrder(userID, customerID uint, discountPercent,
 			return nil, err
 		}
 
+		// Harga beli varian menggantikan harga beli produk
+		buyPrice := product.Price
+		if cart.VariantID != nil {
+			var variant models.ProductVariant
+			if err := tx.First(&variant, *cart.VariantID).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			buyPrice = variant.Price
+		}
+
 		// Buat detail transaksi
 		detail := models.TransactionDetail{
 			TransactionID: transaction.ID,
 			ProductID:     cart.ProductID,
+			VariantID:     cart.VariantID,
 			Qty:           cart.Qty,
 			Price:         cart.Price,
 		}
@@ -143,7 +310,7 @@ func (s *TransactionService) PayOrder(userID, customerID uint, discountPercent,
 			return nil, err
 		}
 
-		buyTotal := product.Price * cart.Qty
+		buyTotal := buyPrice * cart.Qty
 		profitTotal := transaction.GrandTotal - buyTotal
 
 		profit := models.Profit{
@@ -156,10 +323,7 @@ func (s *TransactionService) PayOrder(userID, customerID uint, discountPercent,
 		}
 
 		// Kurangi stok
-		if err := tx.Model(&models.Product{}).
-			Where("id = ?", cart.ProductID).
-			Update("stock", gorm.Expr("stock - ?", cart.Qty)).
-			Error; err != nil {
+		if err := adjustStock(tx, cart.ProductID, cart.VariantID, -cart.Qty); err != nil {
 			tx.Rollback()
 			return nil, err
 		}
@@ -178,10 +342,12 @@ func (s *TransactionService) PayOrder(userID, customerID uint, discountPercent,
 	// Ambil data lengkap
 	var fullTransaction models.Transaction
 	err = s.db.
-		Preload("User").
+		Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
 		Preload("Customer").
 		Preload("TransactionDetails", func(db *gorm.DB) *gorm.DB {
-			return db.Preload("Product")
+			return db.Preload("Product").Preload("Variant")
 		}).
 		First(&fullTransaction, transaction.ID).Error
 
@@ -193,3 +359,76 @@ func (s *TransactionService) PayOrder(userID, customerID uint, discountPercent,
 
 	return &fullTransaction, nil
 }
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrTransactionVoided   = errors.New("transaction is already voided")
+)
+
+// VoidTransaction membatalkan transaksi: stok dikembalikan, profit dihapus,
+// poin loyalty dibalik, piutang yang belum dibayar dihapus, dan transaksi
+// ditandai voided sehingga tidak ikut di laporan. Data transaksi tetap
+// disimpan untuk jejak audit.
+func (s *TransactionService) VoidTransaction(id, userID uint, reason string) (*models.Transaction, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("void reason is required")
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var transaction models.Transaction
+		if err := tx.Preload("TransactionDetails").First(&transaction, id).Error; err != nil {
+			return ErrTransactionNotFound
+		}
+		if transaction.VoidedAt != nil {
+			return ErrTransactionVoided
+		}
+
+		for _, detail := range transaction.TransactionDetails {
+			if err := adjustStock(tx, detail.ProductID, detail.VariantID, detail.Qty); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("transaction_id = ?", transaction.ID).Delete(&models.Profit{}).Error; err != nil {
+			return err
+		}
+
+		if err := removeTransactionReceivable(tx, transaction.ID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if transaction.CustomerID != 0 {
+			if err := lockCustomer(tx, transaction.CustomerID); err != nil {
+				return err
+			}
+		}
+		if err := reverseTransactionPoints(tx, transaction.ID, now); err != nil {
+			return err
+		}
+
+		return tx.Model(&transaction).Omit("TransactionDetails").Updates(map[string]interface{}{
+			"voided_at":   now,
+			"voided_by":   userID,
+			"void_reason": strings.TrimSpace(reason),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var transaction models.Transaction
+	err = s.db.
+		Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Preload("Customer").
+		Preload("TransactionDetails", func(db *gorm.DB) *gorm.DB {
+			return db.Preload("Product").Preload("Variant")
+		}).
+		First(&transaction, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"go-admin/dto"
+	"go-admin/models"
+	"math/big"
+	"mime/multipart"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var errImportHasInvalidRows = errors.New("import has invalid rows")
+
+// ImportUsers membuat user dari file CSV/XLSX dengan kolom name, email dan role.
+// Semua baris divalidasi dulu; jika ada satu baris yang gagal, tidak ada user
+// yang dibuat. Setiap user baru mendapat password sementara yang dikembalikan
+// di laporan.
+func (s *UserService) ImportUsers(file *multipart.FileHeader, dryRun bool) (*dto.UserImportResult, error) {
+	rows, err := readSpreadsheet(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, errors.New("file has no data rows")
+	}
+
+	index := headerIndex(rows[0])
+	for _, column := range []string{"name", "email", "role"} {
+		if _, ok := index[column]; !ok {
+			return nil, errors.New("missing column: " + column)
+		}
+	}
+
+	var roles []models.Role
+	if err := s.db.Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	roleByName := make(map[string]models.Role, len(roles))
+	for _, role := range roles {
+		roleByName[strings.ToLower(role.Name)] = role
+	}
+
+	result := &dto.UserImportResult{DryRun: dryRun}
+	users := make([]*models.User, 0, len(rows)-1)
+	seenEmails := make(map[string]int)
+
+	for i, row := range rows[1:] {
+		item := dto.UserImportRow{
+			Row:   i + 2,
+			Name:  cell(row, index, "name"),
+			Email: strings.ToLower(cell(row, index, "email")),
+			Role:  cell(row, index, "role"),
+		}
+		if item.Name == "" && item.Email == "" && item.Role == "" {
+			continue
+		}
+
+		if item.Name == "" {
+			item.Errors = append(item.Errors, "name is required")
+		}
+
+		if item.Email == "" {
+			item.Errors = append(item.Errors, "email is required")
+		} else if _, err := mail.ParseAddress(item.Email); err != nil {
+			item.Errors = append(item.Errors, "email is invalid")
+		} else if first, ok := seenEmails[item.Email]; ok {
+			item.Errors = append(item.Errors, "email is duplicated in row "+strconv.Itoa(first))
+		} else {
+			seenEmails[item.Email] = item.Row
+			err := checkEmailAvailable(s.db, item.Email, 0)
+			if errors.Is(err, ErrEmailInUse) || errors.Is(err, ErrEmailDeleted) {
+				item.Errors = append(item.Errors, err.Error())
+			} else if err != nil {
+				item.Errors = append(item.Errors, "failed to check email: "+err.Error())
+			}
+		}
+
+		role, ok := roleByName[strings.ToLower(item.Role)]
+		if item.Role == "" {
+			item.Errors = append(item.Errors, "role is required")
+		} else if !ok {
+			item.Errors = append(item.Errors, "role not found")
+		}
+
+		firstName, lastName, _ := strings.Cut(item.Name, " ")
+		users = append(users, &models.User{
+			FirstName: firstName,
+			LastName:  strings.TrimSpace(lastName),
+			Email:     item.Email,
+			RoleId:    role.Id,
+		})
+
+		if len(item.Errors) > 0 {
+			result.Failed++
+		}
+		result.Rows = append(result.Rows, item)
+	}
+
+	if len(result.Rows) == 0 {
+		return nil, errors.New("file has no data rows")
+	}
+	if result.Failed > 0 || dryRun {
+		return result, nil
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i, user := range users {
+			password, err := temporaryPassword()
+			if err != nil {
+				return err
+			}
+			user.SetPassword(password)
+
+			if err := tx.Create(user).Error; err != nil {
+				result.Rows[i].Errors = append(result.Rows[i].Errors, err.Error())
+				return errImportHasInvalidRows
+			}
+
+			result.Rows[i].UserID = user.Id
+			result.Rows[i].TemporaryPassword = password
+		}
+		return nil
+	})
+	if errors.Is(err, errImportHasInvalidRows) {
+		result.Failed = 1
+		for i := range result.Rows {
+			result.Rows[i].UserID = 0
+			result.Rows[i].TemporaryPassword = ""
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Created = len(users)
+	return result, nil
+}
+
+const passwordAlphabet = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func temporaryPassword() (string, error) {
+	b := make([]byte, 10)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
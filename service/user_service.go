@@ -1,49 +1,87 @@
 package service
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"go-admin/models"
 	"gorm.io/gorm"
-	"math"
+	"mime/multipart"
+)
+
+var (
+	ErrEmailInUse    = errors.New("email already in use")
+	ErrEmailDeleted  = errors.New("email belongs to a deleted user, restore that user instead")
+	ErrLastRoleAdmin = errors.New("cannot remove the last active user who can edit roles")
 )
 
 type UserService struct {
-	db *gorm.DB
+	db          *gorm.DB
+	minioClient *MinioService
 }
 
-func NewUserService(db *gorm.DB) *UserService {
-	return &UserService{db: db}
+func NewUserService(db *gorm.DB, minioClient *MinioService) *UserService {
+	return &UserService{
+		db:          db,
+		minioClient: minioClient,
+	}
 }
 
-func (s *UserService) GetAllUsers(page int) fiber.Map {
-	limit := 5
-	offset := (page - 1) * limit
-
-	var users []models.User
-	s.db.Preload("Role").Offset(offset).Limit(limit).Find(&users)
-
-	var total int64
-	s.db.Model(&models.User{}).Count(&total)
+func (s *UserService) WithContext(ctx context.Context) *UserService {
+	return &UserService{
+		db:          s.db.WithContext(ctx),
+		minioClient: s.minioClient,
+	}
+}
 
-	lastPage := math.Ceil(float64(total) / float64(limit))
+// GetAllUsers memakai query language umum (lihat models.ListQuery).
+// filter[status]=deleted ditangani di sini karena butuh Unscoped.
+func (s *UserService) GetAllUsers(query models.ListQuery) (fiber.Map, error) {
+	db := s.db
 
-	return fiber.Map{
-		"data": users,
-		"meta": fiber.Map{
-			"total":     total,
-			"page":      page,
-			"last_page": lastPage,
-		},
+	query, statusFilters := query.Without("status")
+	for _, f := range statusFilters {
+		if f.Op == "eq" && f.Value == "deleted" {
+			db = db.Unscoped().Where("deleted_at IS NOT NULL")
+			continue
+		}
+		query.Filters = append(query.Filters, f)
 	}
+
+	return models.Paginate(db, &models.User{}, query)
 }
 
-func (s *UserService) CreateUser(user *models.User) *models.User {
+func (s *UserService) CreateUser(user *models.User) (*models.User, error) {
+	if err := checkEmailAvailable(s.db, user.Email, 0); err != nil {
+		return nil, err
+	}
 	user.SetPassword("1234")
 	if err := s.db.Create(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// checkEmailAvailable ikut memeriksa user yang sudah di-soft delete karena
+// kolom email tetap unique; user tersebut harus di-restore, bukan dibuat ulang.
+func checkEmailAvailable(db *gorm.DB, email string, exceptID uint) error {
+	if email == "" {
 		return nil
 	}
-	return user
+	var existing models.User
+	err := db.Unscoped().Where("LOWER(email) = LOWER(?) AND id <> ?", email, exceptID).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.DeletedAt.Valid {
+		return ErrEmailDeleted
+	}
+	return ErrEmailInUse
 }
 
 func (s *UserService) GetUser(id uint) (*models.User, error) {
@@ -62,11 +100,8 @@ func (s *UserService) UpdateUser(id uint, userData *models.User) (*models.User,
 
 	// Periksa apakah email sudah digunakan oleh user lain
 	if userData.Email != "" && userData.Email != user.Email {
-		var existingUser models.User
-		if err := s.db.Where("email = ?", userData.Email).First(&existingUser).Error; err == nil {
-			if existingUser.Id != id {
-				return nil, errors.New("email already in use")
-			}
+		if err := checkEmailAvailable(s.db, userData.Email, id); err != nil {
+			return nil, err
 		}
 	}
 
@@ -80,7 +115,16 @@ func (s *UserService) UpdateUser(id uint, userData *models.User) (*models.User,
 	if userData.Email != "" {
 		user.Email = userData.Email
 	}
-	if userData.RoleId != 0 {
+	if userData.RoleId != 0 && userData.RoleId != user.RoleId {
+		canEditRoles, err := s.roleCanEditRoles(userData.RoleId)
+		if err != nil {
+			return nil, err
+		}
+		if !canEditRoles {
+			if err := s.ensureOtherRoleAdmin(&user); err != nil {
+				return nil, err
+			}
+		}
 		user.RoleId = userData.RoleId
 	}
 
@@ -96,10 +140,140 @@ func (s *UserService) UpdateUser(id uint, userData *models.User) (*models.User,
 	return &user, nil
 }
 
+// DeleteUser melakukan soft delete supaya transaksi lama tetap bisa
+// menampilkan nama kasir.
 func (s *UserService) DeleteUser(id uint) error {
-	result := s.db.Delete(&models.User{}, id)
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+	var user models.User
+	if err := s.db.First(&user, id).Error; err != nil {
+		return err
+	}
+	if err := s.ensureOtherRoleAdmin(&user); err != nil {
+		return err
+	}
+	return s.db.Delete(&user).Error
+}
+
+// ensureOtherRoleAdmin mencegah user terakhir yang bisa mengubah role
+// dihapus, dinonaktifkan atau dipindah ke role lain, karena tidak ada lagi
+// yang bisa memulihkannya.
+func (s *UserService) ensureOtherRoleAdmin(user *models.User) error {
+	roleAdmins := s.db.Model(&models.User{}).
+		Joins("JOIN role_permissions ON role_permissions.role_id = users.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("permissions.name = ?", "edit_roles")
+
+	var isAdmin int64
+	if err := roleAdmins.Session(&gorm.Session{}).Where("users.id = ?", user.Id).Count(&isAdmin).Error; err != nil {
+		return err
+	}
+	if isAdmin == 0 {
+		return nil
+	}
+
+	var others int64
+	if err := roleAdmins.Where("users.id <> ? AND users.status <> ?", user.Id, models.UserStatusInactive).Count(&others).Error; err != nil {
+		return err
+	}
+	if others == 0 {
+		return ErrLastRoleAdmin
 	}
 	return nil
 }
+
+func (s *UserService) roleCanEditRoles(roleID uint) (bool, error) {
+	var count int64
+	err := s.db.Table("role_permissions").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("role_permissions.role_id = ? AND permissions.name = ?", roleID, "edit_roles").
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *UserService) RestoreUser(id uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.Unscoped().Where("deleted_at IS NOT NULL").First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Unscoped().Model(&user).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+	return s.GetUser(id)
+}
+
+func (s *UserService) SetStatus(id uint, status string) (*models.User, error) {
+	if status != models.UserStatusActive && status != models.UserStatusInactive {
+		return nil, errors.New("invalid status")
+	}
+
+	var user models.User
+	if err := s.db.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	if status == models.UserStatusInactive {
+		if err := s.ensureOtherRoleAdmin(&user); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.db.Model(&user).Update("status", status).Error; err != nil {
+		return nil, err
+	}
+	return s.GetUser(id)
+}
+
+// AvatarSize adalah ukuran sisi thumbnail avatar dalam pixel.
+const AvatarSize = 256
+
+func (s *UserService) UploadAvatar(id uint, file *multipart.FileHeader) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+
+	fileSrc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer fileSrc.Close()
+
+	thumbnail, err := squareThumbnail(fileSrc, AvatarSize)
+	if err != nil {
+		return nil, err
+	}
+
+	avatarKey, err := s.minioClient.UploadFile(bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg")
+	if err != nil {
+		return nil, err
+	}
+
+	oldAvatarKey := user.AvatarKey
+	if err := s.db.Model(&user).Update("avatar_key", avatarKey).Error; err != nil {
+		_ = s.minioClient.DeleteFile(avatarKey)
+		return nil, err
+	}
+
+	if oldAvatarKey != "" {
+		if err := s.minioClient.DeleteFile(oldAvatarKey); err != nil {
+			fmt.Printf("Warning: failed to delete old avatar: %v\n", err)
+		}
+	}
+
+	return s.GetUser(id)
+}
+
+func (s *UserService) DeleteAvatar(id uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+
+	if user.AvatarKey != "" {
+		if err := s.minioClient.DeleteFile(user.AvatarKey); err != nil {
+			return nil, fmt.Errorf("failed to delete avatar: %w", err)
+		}
+		if err := s.db.Model(&user).Update("avatar_key", "").Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetUser(id)
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go-admin/dto"
+	"go-admin/models"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrVariantNotFound = errors.New("variant not found")
+	ErrVariantRequired = errors.New("product has variants, variant_id is required")
+	ErrBarcodeInUse    = errors.New("barcode already in use")
+)
+
+func (s *ProductService) GetVariants(productID uint) ([]models.ProductVariant, error) {
+	if err := s.db.First(&models.Product{}, productID).Error; err != nil {
+		return nil, ErrProductNotFound
+	}
+	variants := []models.ProductVariant{}
+	err := s.db.Where("product_id = ?", productID).Order("id").Find(&variants).Error
+	return variants, err
+}
+
+// SetOptions mengatur sumbu varian produk. Sumbu hanya bisa diubah selama
+// produk belum punya varian.
+func (s *ProductService) SetOptions(productID uint, options []string) (*models.Product, error) {
+	var product models.Product
+	if err := s.db.First(&product, productID).Error; err != nil {
+		return nil, ErrProductNotFound
+	}
+
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" || seen[option] {
+			continue
+		}
+		seen[option] = true
+		normalized = append(normalized, option)
+	}
+
+	var count int64
+	if err := s.db.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 && !sameOptions(product.Options, normalized) {
+		return nil, errors.New("delete all variants before changing options")
+	}
+
+	product.Options = normalized
+	if err := s.db.Model(&product).Select("options").Updates(&product).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (s *ProductService) CreateVariant(productID uint, req dto.VariantRequest) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.First(&product, productID).Error; err != nil {
+			return ErrProductNotFound
+		}
+
+		variant = models.ProductVariant{ProductID: product.ID}
+		applyVariantRequest(&variant, req)
+		if err := validateVariant(tx, &product, &variant); err != nil {
+			return err
+		}
+		if err := tx.Create(&variant).Error; err != nil {
+			return err
+		}
+		return syncProductStock(tx, product.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (s *ProductService) UpdateVariant(productID, variantID uint, req dto.VariantRequest) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.First(&product, productID).Error; err != nil {
+			return ErrProductNotFound
+		}
+		if err := tx.Where("product_id = ?", product.ID).First(&variant, variantID).Error; err != nil {
+			return ErrVariantNotFound
+		}
+
+		applyVariantRequest(&variant, req)
+		if err := validateVariant(tx, &product, &variant); err != nil {
+			return err
+		}
+		if err := tx.Save(&variant).Error; err != nil {
+			return err
+		}
+		return syncProductStock(tx, product.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (s *ProductService) DeleteVariant(productID, variantID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var variant models.ProductVariant
+		if err := tx.Where("product_id = ?", productID).First(&variant, variantID).Error; err != nil {
+			return ErrVariantNotFound
+		}
+		if err := tx.Where("variant_id = ?", variant.ID).Delete(&models.Cart{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&variant).Error; err != nil {
+			return err
+		}
+		return syncProductStock(tx, productID)
+	})
+}
+
+func applyVariantRequest(variant *models.ProductVariant, req dto.VariantRequest) {
+	variant.SKU = strings.TrimSpace(req.SKU)
+	variant.Barcode = strings.TrimSpace(req.Barcode)
+	variant.Price = req.Price
+	variant.SellPrice = req.SellPrice
+	variant.Stock = req.Stock
+
+	variant.Options = make(map[string]string, len(req.Options))
+	for key, value := range req.Options {
+		variant.Options[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if variant.Barcode == "" {
+		variant.Barcode = generateBarcode()
+	}
+}
+
+// validateVariant memastikan opsi varian sesuai sumbu produk dan belum
+// dipakai varian lain, serta barcode dan SKU tidak bentrok.
+func validateVariant(tx *gorm.DB, product *models.Product, variant *models.ProductVariant) error {
+	if len(product.Options) == 0 {
+		return errors.New("set product options before adding variants")
+	}
+	if variant.Price < 0 || variant.SellPrice < 0 || variant.Stock < 0 {
+		return errors.New("price, sell_price and stock must not be negative")
+	}
+	if len(variant.Options) != len(product.Options) {
+		return fmt.Errorf("variant options must be exactly: %s", strings.Join(product.Options, ", "))
+	}
+	for _, option := range product.Options {
+		if variant.Options[option] == "" {
+			return fmt.Errorf("option %q is required", option)
+		}
+	}
+
+	var others []models.ProductVariant
+	if err := tx.Where("product_id = ? AND id <> ?", product.ID, variant.ID).Find(&others).Error; err != nil {
+		return err
+	}
+	for _, other := range others {
+		if variantLabel(other) == variantLabel(*variant) {
+			return errors.New("variant with the same options already exists")
+		}
+	}
+
+	if err := checkBarcode(tx, variant.Barcode, variant.ID); err != nil {
+		return err
+	}
+
+	if variant.SKU != "" {
+		if err := lockCode(tx, "sku:"+variant.SKU); err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.ProductVariant{}).
+			Where("sku = ? AND id <> ?", variant.SKU, variant.ID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("sku already in use")
+		}
+	}
+	return nil
+}
+
+// checkBarcode memastikan barcode belum dipakai produk maupun varian lain
+// (variantID adalah varian yang sedang diubah, 0 untuk produk baru). Harus
+// dipanggil di dalam transaksi yang sama dengan insert/update-nya.
+func checkBarcode(tx *gorm.DB, barcode string, variantID uint) error {
+	if err := lockCode(tx, "barcode:"+barcode); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := tx.Model(&models.Product{}).Where("barcode = ?", barcode).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		if err := tx.Model(&models.ProductVariant{}).
+			Where("barcode = ? AND id <> ?", barcode, variantID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+	}
+	if count > 0 {
+		return ErrBarcodeInUse
+	}
+	return nil
+}
+
+// lockCode menahan sebuah kode (barcode atau SKU) sampai transaksi selesai,
+// supaya dua request yang memakai kode yang sama tidak sama-sama lolos
+// pengecekan. Barcode dipakai di tabel products dan product_variants
+// sekaligus, jadi tidak bisa dijaga dengan unique index. SQLite sudah
+// menjalankan transaksi tulis satu per satu.
+func lockCode(tx *gorm.DB, code string) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", code).Error
+}
+
+// variantLabel menyusun opsi varian menjadi teks, misalnya "color: red, size: M".
+func variantLabel(variant models.ProductVariant) string {
+	keys := make([]string, 0, len(variant.Options))
+	for key := range variant.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key + ": " + variant.Options[key]
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sameOptions(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// adjustStock mengubah stok produk, atau stok varian jika variantID diisi.
+// Stok produk yang punya varian selalu jumlah stok variannya.
+func adjustStock(tx *gorm.DB, productID uint, variantID *uint, qty float64) error {
+	if variantID == nil {
+		return tx.Model(&models.Product{}).
+			Where("id = ?", productID).
+			Update("stock", gorm.Expr("stock + ?", qty)).Error
+	}
+
+	if err := tx.Model(&models.ProductVariant{}).
+		Where("id = ?", *variantID).
+		Update("stock", gorm.Expr("stock + ?", qty)).Error; err != nil {
+		return err
+	}
+	return syncProductStock(tx, productID)
+}
+
+func syncProductStock(tx *gorm.DB, productID uint) error {
+	var count int64
+	if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", productID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	var stock float64
+	if err := tx.Model(&models.ProductVariant{}).
+		Select("COALESCE(SUM(stock), 0)").
+		Where("product_id = ?", productID).
+		Scan(&stock).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Product{}).Where("id = ?", productID).Update("stock", stock).Error
+}